@@ -0,0 +1,38 @@
+package qlog
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"go.uber.org/zap"
+)
+
+// apiGatewayFields is the Extractor of events.APIGatewayProxyRequestContext,
+// given by value or pointer, as received by Lambda functions behind API
+// Gateway.
+func apiGatewayFields(ctx interface{}) (fields []zap.Field) {
+	var rc events.APIGatewayProxyRequestContext
+	switch value := ctx.(type) {
+	case events.APIGatewayProxyRequestContext:
+		rc = value
+	case *events.APIGatewayProxyRequestContext:
+		if value == nil {
+			return nil
+		}
+		rc = *value
+	}
+	for _, f := range []struct {
+		key   string
+		value string
+	}{
+		{KeyAPIRequestID, rc.RequestID},
+		{KeyDomainName, rc.DomainName},
+		{KeyDomainPrefix, rc.DomainPrefix},
+		{KeyProtocol, rc.Protocol},
+		{KeySourceIP, rc.Identity.SourceIP},
+	} {
+		if !stg.IsEmpty(&f.value) {
+			fields = append(fields, zap.String(f.key, f.value))
+		}
+	}
+	return fields
+}
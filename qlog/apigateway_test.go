@@ -0,0 +1,45 @@
+package qlog
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+)
+
+func TestAPIGatewayFields(t *testing.T) {
+	rc := events.APIGatewayProxyRequestContext{
+		RequestID:    "c6af9ac6-7b61-11e6-9a41-93e8deadbeef",
+		DomainName:   "tenant.execute-api.us-east-1.amazonaws.com",
+		DomainPrefix: "tenant",
+		Protocol:     "HTTP/1.1",
+		Identity:     events.APIGatewayRequestIdentity{SourceIP: "203.0.113.7"},
+	}
+	full := map[string]interface{}{
+		KeyAPIRequestID: rc.RequestID,
+		KeyDomainName:   rc.DomainName,
+		KeyDomainPrefix: "tenant",
+		KeyProtocol:     "HTTP/1.1",
+		KeySourceIP:     "203.0.113.7",
+	}
+	tests := []struct {
+		name string
+		ctx  interface{}
+		want map[string]interface{}
+	}{
+		{"value", rc, full},
+		{"pointer", &rc, full},
+		{"nil pointer", (*events.APIGatewayProxyRequestContext)(nil), map[string]interface{}{}},
+		{"partial", events.APIGatewayProxyRequestContext{RequestID: "r"}, map[string]interface{}{KeyAPIRequestID: "r"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(tt.ctx)
+			l.Info("hello")
+
+			if got := logs.All()[0].ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package qlog
+
+import (
+	"io"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Audit logs an audit event, who did what, at InfoLevel with action as the
+// message and an "audit":true field, followed by the key/value pairs. Audit
+// entries are never sampled nor deduplicated, and are written to the audit
+// writer set with WithAuditWriter, if any, instead of the operational logs.
+func (l *Logger) Audit(action string, keysAndValues ...interface{}) {
+	l.logAudit(action, keysAndValues)
+}
+
+// logAudit is like log for the audit entries.
+func (l *Logger) logAudit(action string, keysAndValues []interface{}) {
+	if ce := l.auditOutput().Check(zapcore.InfoLevel, action); ce != nil {
+		nrfs := append(l.logFromContext(l.Context), zap.Bool("audit", true))
+		ce.Write(append(nrfs, kvFields(keysAndValues)...)...)
+	}
+}
+
+// auditOutput returns the zap logger the audit entries are written to: the
+// one kept by the Logger constructors, which writes to the same output as
+// l.Zap without sampling, or l.output() for a Logger built otherwise.
+func (l *Logger) auditOutput() *zap.Logger {
+	if l.audit != nil {
+		return l.audit
+	}
+	return l.output()
+}
+
+// WithAuditWriter returns a child logger writing its audit entries as JSON to
+// w, from InfoLevel whatever the level of the logger, and no longer to the
+// operational logs. The other entries are not affected. Sync and Close also
+// flush w. The writes to w are serialized, so w needn't be safe for concurrent
+// use.
+func (l *Logger) WithAuditWriter(w io.Writer) *Logger {
+	audit := productionCore(zapcore.Lock(zapcore.AddSync(w)), zap.NewAtomicLevelAt(zapcore.InfoLevel))
+	child := *l
+	out := l.audit
+	if out == nil {
+		out = l.Zap
+	}
+	child.audit = out.WithOptions(l.wrapCore(func(zapcore.Core) zapcore.Core {
+		return audit
+	}))
+	child.auditWriter = true
+	return &child
+}
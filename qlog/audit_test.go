@@ -0,0 +1,132 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAudit(t *testing.T) {
+	l, logs := NewObserved(ContextWithRequestID(context.Background(), "req-1"))
+	l.Audit("user.deleted", "actor", "admin", "user_id", 7)
+
+	entry := logs.All()[0]
+	if entry.Message != "user.deleted" || entry.Level != zapcore.InfoLevel {
+		t.Errorf("entry = %q at %v, want user.deleted at info", entry.Message, entry.Level)
+	}
+	want := map[string]interface{}{KeyXRequestID: "req-1", "audit": true, "actor": "admin", "user_id": int64(7)}
+	if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestAuditNotSampledNorDeduplicated(t *testing.T) {
+	out := captureStderr(t, func() {
+		l := NewProductionSampled(nil, 1, 0).WithDedup(time.Hour)
+		for i := 0; i < 3; i++ {
+			l.Audit("login")
+			l.Info("login")
+		}
+		_ = l.Sync()
+	})
+
+	var audits, infos int
+	for _, entry := range decodeEntries(t, out) {
+		if entry["audit"] == true {
+			audits++
+		} else {
+			infos++
+		}
+		if _, ok := entry["logger"]; ok {
+			t.Errorf("entry %v has a logger name", entry)
+		}
+	}
+	if audits != 3 || infos != 1 {
+		t.Errorf("logged %d audit and %d info entries, want 3 and 1", audits, infos)
+	}
+}
+
+func TestWithAuditWriter(t *testing.T) {
+	var audit bytes.Buffer
+	base, logs := NewObserved(nil)
+	if err := base.SetLevel(ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	l := base.WithAuditWriter(&audit).WithRequestID("req-1")
+	l.Audit("user.deleted", KeyAPIKey, "sk_live_abcd1234")
+	l.Error("operational")
+	l.Named("audit").Error("named")
+
+	entries := decodeEntries(t, audit.String())
+	if len(entries) != 1 {
+		t.Fatalf("audit writer holds %d entries, want 1: %s", len(entries), audit.String())
+	}
+	entry := entries[0]
+	if entry["message"] != "user.deleted" || entry["level"] != "info" || entry[KeyXRequestID] != "req-1" {
+		t.Errorf("audit entry = %v", entry)
+	}
+	if entry[KeyAPIKey] != "****1234" {
+		t.Errorf("%s = %v, want it masked", KeyAPIKey, entry[KeyAPIKey])
+	}
+	if n := logs.FilterField(zap.Bool("audit", true)).Len(); n != 0 {
+		t.Errorf("operational logs hold %d audit entries, want none", n)
+	}
+	if n := logs.Len(); n != 2 {
+		t.Errorf("operational logs hold %d entries, want 2", n)
+	}
+}
+
+func TestWithAuditWriterSync(t *testing.T) {
+	w := &syncNotifier{synced: make(chan struct{}, 1)}
+	l := NewNop().WithAuditWriter(w)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.synced:
+	default:
+		t.Error("Sync didn't flush the audit writer")
+	}
+}
+
+func TestWithAuditWriterConcurrent(t *testing.T) {
+	var audit bytes.Buffer
+	l := NewNop().WithAuditWriter(&audit)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l.Audit("login", "user_id", j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if entries := decodeEntries(t, audit.String()); len(entries) != 8*50 {
+		t.Errorf("got %d audit entries, want %d", len(entries), 8*50)
+	}
+}
+
+func TestNamedAudit(t *testing.T) {
+	var audit bytes.Buffer
+	base, logs := NewObserved(nil)
+	l := base.WithAuditWriter(&audit).Named("payments")
+	l.Audit("refund.approved")
+	l.Info("operational")
+
+	entries := decodeEntries(t, audit.String())
+	if len(entries) != 1 || entries[0]["logger"] != "payments" {
+		t.Errorf("audit entries = %v, want the logger name payments", entries)
+	}
+	if got := logs.All()[0].LoggerName; got != "payments" {
+		t.Errorf("operational logger name = %q, want payments", got)
+	}
+}
@@ -0,0 +1,46 @@
+package qlog
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Clock is the source of the current time of a Logger, the system clock by
+// default, which tests can replace with a fixed clock to get deterministic
+// timestamps.
+type Clock interface {
+	Now() time.Time
+}
+
+// WithClock returns a child logger reading the current time from c, for the
+// timestamp of its entries, for Timer and for the dedup windows of WithDedup.
+func (l *Logger) WithClock(c Clock) *Logger {
+	child := *l
+	child.clock = c
+	child.Zap = l.Zap.WithOptions(zap.WithClock(zapClock{c}))
+	if l.audit != nil {
+		child.audit = l.audit.WithOptions(zap.WithClock(zapClock{c}))
+	}
+	return &child
+}
+
+// now returns the current time of the clock of l.
+func (l *Logger) now() time.Time {
+	if l.clock != nil {
+		return l.clock.Now()
+	}
+	return time.Now()
+}
+
+// zapClock adapts a Clock to a zapcore.Clock.
+type zapClock struct {
+	Clock
+}
+
+func (zapClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+var _ zapcore.Clock = zapClock{}
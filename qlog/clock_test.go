@@ -0,0 +1,36 @@
+package qlog
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWithClock(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	var buf, audit bytes.Buffer
+	l := NewProductionWithWriter(&buf, nil).WithAuditWriter(&audit).WithClock(clock)
+	l.Info("first")
+	clock.Add(time.Second)
+	l.Info("second")
+	l.Audit("order.refunded")
+
+	want := []float64{
+		float64(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()) / 1e9,
+		float64(time.Date(2024, 1, 2, 3, 4, 6, 0, time.UTC).UnixNano()) / 1e9,
+	}
+	entries := decodeEntries(t, buf.String())
+	if len(entries) != len(want) {
+		t.Fatalf("logged %d entries, want %d: %s", len(entries), len(want), buf.String())
+	}
+	for i, entry := range entries {
+		if entry["ts"] != want[i] {
+			t.Errorf("%s ts = %v, want %v", entry["message"], entry["ts"], want[i])
+		}
+	}
+	audits := decodeEntries(t, audit.String())
+	if len(audits) != 1 || audits[0]["ts"] != want[1] {
+		t.Errorf("audit entries = %v, want ts %v", audits, want[1])
+	}
+}
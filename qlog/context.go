@@ -0,0 +1,46 @@
+package qlog
+
+import (
+	"context"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// contextFields is the Extractor of context.Context. It reads the request and
+// session ids stored by the qlog middlewares and the OpenTelemetry span
+// context.
+func contextFields(ctx interface{}) (fields []zap.Field) {
+	value := ctx.(context.Context)
+	if uuid := RequestIDFromContext(value); !stg.IsEmpty(&uuid) {
+		fields = append(fields, zap.String(KeyXRequestID, uuid))
+	}
+	if session := SessionIDFromContext(value); !stg.IsEmpty(&session) {
+		fields = append(fields, zap.String(KeySessionID, session))
+	}
+	if sc := trace.SpanContextFromContext(value); sc.IsValid() {
+		fields = append(fields,
+			zap.String(KeyTraceID, sc.TraceID().String()),
+			zap.String(KeySpanID, sc.SpanID().String()),
+		)
+	}
+	return fields
+}
+
+// NewContext returns a copy of ctx carrying l, to be retrieved down the call
+// chain with FromContext.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns the Logger stored on ctx by NewContext or
+// UnaryServerInterceptor. When there is none, the package-level Logger
+// returned by L is bound to ctx and returned, which writes nothing unless
+// SetDefault was called.
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(loggerKey).(*Logger); ok && l != nil {
+		return l
+	}
+	return L().WithContext(ctx)
+}
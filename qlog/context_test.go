@@ -0,0 +1,48 @@
+package qlog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextTraceFields(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	sc := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		wantTrace interface{}
+		wantSpan  interface{}
+	}{
+		{"span", trace.ContextWithSpanContext(context.Background(), sc), traceID.String(), spanID.String()},
+		{"no span", context.Background(), nil, nil},
+		{"invalid span", trace.ContextWithSpanContext(context.Background(), trace.SpanContext{}), nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields := fieldMap(contextFields(tt.ctx))
+			if fields[KeyTraceID] != tt.wantTrace || fields[KeySpanID] != tt.wantSpan {
+				t.Errorf("fields = %v, want trace %v and span %v", fields, tt.wantTrace, tt.wantSpan)
+			}
+		})
+	}
+}
+
+func TestNewContext(t *testing.T) {
+	l, _ := NewObserved(nil)
+	if got := FromContext(NewContext(context.Background(), l)); got != l {
+		t.Errorf("FromContext(NewContext(l)) = %p, want %p", got, l)
+	}
+
+	ctx := NewContext(ContextWithRequestID(context.Background(), "req-1"), nil)
+	got := FromContext(ctx)
+	if got == nil {
+		t.Fatal("FromContext(NewContext(nil)) = nil, want the default logger")
+	}
+	if got.Context != ctx {
+		t.Errorf("FromContext(NewContext(nil)) isn't bound to ctx")
+	}
+}
@@ -0,0 +1,147 @@
+package qlog
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithDedup returns a child logger collapsing identical entries, with the
+// same message and level, logged within window: the first entry is held until
+// the window ends and then written once, with an "occurrences" field counting
+// how many times it was logged when more than once. The window starts at the
+// timestamp of the first entry, read from the clock of the logger, and an
+// identical entry logged past it writes the held one and starts a new window.
+// DPanicLevel and above entries and audit entries are never held. Sync writes
+// the held entries right away.
+func (l *Logger) WithDedup(window time.Duration) *Logger {
+	state := &dedupState{pending: make(map[dedupKey]*dedupEntry)}
+	child := *l
+	child.Zap = l.Zap.WithOptions(l.wrapCore(func(core zapcore.Core) zapcore.Core {
+		return &dedupCore{Core: core, window: window, state: state}
+	}))
+	return &child
+}
+
+// dedupKey identifies identical entries.
+type dedupKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// dedupEntry is an entry held until the end of its window.
+type dedupEntry struct {
+	core        zapcore.Core
+	ent         zapcore.Entry
+	fields      []zapcore.Field
+	occurrences int
+}
+
+// dedupState holds the pending entries, shared by a dedupCore and the cores
+// it derives with With.
+type dedupState struct {
+	mu      sync.Mutex
+	pending map[dedupKey]*dedupEntry
+}
+
+// flush writes the pending entry of key, if any.
+func (s *dedupState) flush(key dedupKey) error {
+	s.mu.Lock()
+	e, ok := s.pending[key]
+	delete(s.pending, key)
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return e.write()
+}
+
+// flushEntry writes e if it is still the pending entry of key, and not one
+// held in a later window.
+func (s *dedupState) flushEntry(key dedupKey, e *dedupEntry) error {
+	s.mu.Lock()
+	ok := s.pending[key] == e
+	if ok {
+		delete(s.pending, key)
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return e.write()
+}
+
+// write writes the held entry, with its occurrences when more than one.
+func (e *dedupEntry) write() error {
+	fields := e.fields
+	if e.occurrences > 1 {
+		fields = append(fields, zap.Int("occurrences", e.occurrences))
+	}
+	return e.core.Write(e.ent, fields)
+}
+
+// dedupCore is a zapcore.Core holding and counting identical entries.
+type dedupCore struct {
+	zapcore.Core
+	window time.Duration
+	state  *dedupState
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{
+		Core:   c.Core.With(fields),
+		window: c.window,
+		state:  c.state,
+	}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level >= zapcore.DPanicLevel {
+		return c.Core.Check(ent, ce)
+	}
+	if next := checked(c.Core, ent); next != nil {
+		return ce.AddCore(ent, &dedupCore{Core: next, window: c.window, state: c.state})
+	}
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := dedupKey{ent.Level, ent.Message}
+	c.state.mu.Lock()
+	held, ok := c.state.pending[key]
+	if ok && ent.Time.Before(held.ent.Time.Add(c.window)) {
+		held.occurrences++
+		c.state.mu.Unlock()
+		return nil
+	}
+	e := &dedupEntry{
+		core:        c.Core,
+		ent:         ent,
+		fields:      append([]zapcore.Field(nil), fields...),
+		occurrences: 1,
+	}
+	c.state.pending[key] = e
+	c.state.mu.Unlock()
+	time.AfterFunc(c.window, func() { _ = c.state.flushEntry(key, e) })
+	if ok {
+		return held.write()
+	}
+	return nil
+}
+
+func (c *dedupCore) Sync() error {
+	c.state.mu.Lock()
+	keys := make([]dedupKey, 0, len(c.state.pending))
+	for key := range c.state.pending {
+		keys = append(keys, key)
+	}
+	c.state.mu.Unlock()
+	var err error
+	for _, key := range keys {
+		err = multierr.Append(err, c.state.flush(key))
+	}
+	return multierr.Append(err, c.Core.Sync())
+}
@@ -0,0 +1,99 @@
+package qlog
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithDedup(t *testing.T) {
+	base, logs := NewObserved(nil)
+	l := base.WithDedup(time.Hour)
+	for i := 0; i < 3; i++ {
+		l.Error("db down", "attempt", i)
+	}
+	l.Warn("db down")
+	l.With().Error("db down")
+	l.DPanic("bypass")
+
+	if got := logs.Len(); got != 1 {
+		t.Fatalf("logged %d entries before the window ends, want the dpanic one", got)
+	}
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	errs := logs.FilterMessage("db down").FilterLevelExact(zapcore.ErrorLevel).All()
+	if len(errs) != 1 {
+		t.Fatalf("logged %d error entries, want 1", len(errs))
+	}
+	fields := errs[0].ContextMap()
+	if fields["occurrences"] != int64(4) || fields["attempt"] != int64(0) {
+		t.Errorf("fields = %v, want the first entry with 4 occurrences", fields)
+	}
+	warns := logs.FilterMessage("db down").FilterLevelExact(zapcore.WarnLevel).All()
+	if len(warns) != 1 {
+		t.Fatalf("logged %d warn entries, want 1", len(warns))
+	}
+	if _, ok := warns[0].ContextMap()["occurrences"]; ok {
+		t.Error("single entry has an occurrences field")
+	}
+}
+
+func TestWithDedupWindow(t *testing.T) {
+	base, logs := NewObserved(nil)
+	l := base.WithDedup(10 * time.Millisecond)
+	l.Info("tick")
+	l.Info("tick")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := logs.FilterMessage("tick").Len(); got != 1 {
+		t.Fatalf("logged %d entries once the window ended, want 1", got)
+	}
+	l.Info("tick")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if got := logs.FilterMessage("tick").Len(); got != 2 {
+		t.Errorf("logged %d entries, want a new one after the window", got)
+	}
+}
+
+func TestWithDedupClock(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		build func(l *Logger, c Clock) *Logger
+	}{
+		{"clock first", func(l *Logger, c Clock) *Logger { return l.WithClock(c).WithDedup(time.Hour) }},
+		{"dedup first", func(l *Logger, c Clock) *Logger { return l.WithDedup(time.Hour).WithClock(c) }},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+			base, logs := NewObserved(nil)
+			l := tt.build(base, clock)
+			l.Info("tick")
+			clock.Add(30 * time.Minute)
+			l.Info("tick")
+			if got := logs.Len(); got != 0 {
+				t.Fatalf("logged %d entries within the window, want 0", got)
+			}
+
+			clock.Add(time.Hour)
+			l.Info("tick")
+			entries := logs.FilterMessage("tick").All()
+			if len(entries) != 1 || entries[0].ContextMap()["occurrences"] != int64(2) {
+				t.Fatalf("entries = %v, want the first window with 2 occurrences", entries)
+			}
+			if err := l.Sync(); err != nil {
+				t.Fatal(err)
+			}
+			entries = logs.FilterMessage("tick").All()
+			if len(entries) != 2 || !entries[1].Time.Equal(clock.Now()) {
+				t.Errorf("entries = %v, want the second window held since %v", entries, clock.Now())
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package qlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultLogger holds the Logger returned by L.
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	defaultLogger.Store(NewNop())
+}
+
+// SetDefault replaces the package-level Logger returned by L and used by the
+// package-level logging functions. A nil l restores the silent default, a
+// Logger built with NewNop. It is safe for concurrent use.
+func SetDefault(l *Logger) {
+	if l == nil {
+		l = NewNop()
+	}
+	defaultLogger.Store(l)
+}
+
+// L returns the package-level Logger set with SetDefault. It never returns
+// nil: until SetDefault is called it is a Logger that writes nothing.
+func L() *Logger {
+	return defaultLogger.Load()
+}
+
+// Error logs a message at ErrorLevel on the package-level Logger.
+func Error(msg string, keysAndValues ...interface{}) {
+	L().log(zapcore.ErrorLevel, msg, keysAndValues)
+}
+
+// Warn logs a message at WarnLevel on the package-level Logger.
+func Warn(msg string, keysAndValues ...interface{}) {
+	L().log(zapcore.WarnLevel, msg, keysAndValues)
+}
+
+// Info logs a message at InfoLevel on the package-level Logger.
+func Info(msg string, keysAndValues ...interface{}) {
+	L().log(zapcore.InfoLevel, msg, keysAndValues)
+}
+
+// Debug logs a message at DebugLevel on the package-level Logger.
+func Debug(msg string, keysAndValues ...interface{}) {
+	L().log(zapcore.DebugLevel, msg, keysAndValues)
+}
@@ -0,0 +1,52 @@
+package qlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSetDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	if L() == nil {
+		t.Fatal("L() = nil before SetDefault")
+	}
+	l, logs := NewObserved(nil)
+	SetDefault(l)
+	Error("error")
+	Warn("warn")
+	Info("info", "k", "v")
+	Debug("debug")
+
+	entries := logs.All()
+	if len(entries) != 4 {
+		t.Fatalf("logged %d entries, want 4", len(entries))
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Caller.File, "default_test.go") {
+			t.Errorf("%s: caller = %s, want the log site", entry.Message, entry.Caller)
+		}
+	}
+
+	SetDefault(nil)
+	Info("silent")
+	if L() == nil || logs.Len() != 4 {
+		t.Error("SetDefault(nil) didn't restore the silent default")
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+	stored, storedLogs := NewObserved(nil)
+	FromContext(NewContext(context.Background(), stored)).Info("stored")
+	if n := storedLogs.Len(); n != 1 {
+		t.Errorf("stored logger logged %d entries, want 1", n)
+	}
+
+	def, defLogs := NewObserved(nil)
+	SetDefault(def)
+	FromContext(ContextWithRequestID(context.Background(), "req-1")).Info("default")
+	if got := defLogs.All()[0].ContextMap()[KeyXRequestID]; got != "req-1" {
+		t.Errorf("%s = %v, want the default logger bound to ctx", KeyXRequestID, got)
+	}
+}
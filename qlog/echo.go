@@ -0,0 +1,29 @@
+package qlog
+
+import (
+	"net/http"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"go.uber.org/zap"
+)
+
+// echoContext is the subset of labstack/echo's echo.Context used to extract
+// logging fields. Matching on the methods instead of importing echo keeps the
+// dependency out of services that don't use it.
+type echoContext interface {
+	Get(key string) interface{}
+	Request() *http.Request
+}
+
+// echoFields is the Extractor of echo.Context.
+func echoFields(ctx interface{}) (fields []zap.Field) {
+	value := ctx.(echoContext)
+	uuid, _ := value.Get("request_id").(string)
+	if req := value.Request(); stg.IsEmpty(&uuid) && req != nil {
+		uuid = req.Header.Get(headerXRequestID)
+	}
+	if !stg.IsEmpty(&uuid) {
+		fields = append(fields, zap.String(KeyXRequestID, uuid))
+	}
+	return fields
+}
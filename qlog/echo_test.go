@@ -0,0 +1,40 @@
+package qlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeEchoContext implements echoContext like echo.Context does.
+type fakeEchoContext struct {
+	values map[string]interface{}
+	req    *http.Request
+}
+
+func (c fakeEchoContext) Get(key string) interface{} { return c.values[key] }
+func (c fakeEchoContext) Request() *http.Request     { return c.req }
+
+func TestEchoFields(t *testing.T) {
+	withHeader := httptest.NewRequest("GET", "/", nil)
+	withHeader.Header.Set("X-Request-ID", "from-header")
+	tests := []struct {
+		name string
+		ctx  fakeEchoContext
+		want interface{}
+	}{
+		{"context value", fakeEchoContext{map[string]interface{}{"request_id": "from-ctx"}, withHeader}, "from-ctx"},
+		{"header fallback", fakeEchoContext{nil, withHeader}, "from-header"},
+		{"nil request", fakeEchoContext{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(tt.ctx)
+			l.Info("hello")
+
+			if got := logs.All()[0].ContextMap()[KeyXRequestID]; got != tt.want {
+				t.Errorf("%s = %v, want %v", KeyXRequestID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package qlog
+
+import (
+	"errors"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Coder is implemented by errors exposing an application error code. ErrorErr
+// attaches the code of the first error in the chain implementing it.
+type Coder interface {
+	Code() string
+}
+
+// ErrorErr logs err at ErrorLevel using err.Error() as the message. The error
+// is attached under "error", the deepest error of its wrap chain under "cause"
+// and, when an error in the chain implements Coder, its code under "code".
+func (l *Logger) ErrorErr(err error, keysAndValues ...interface{}) {
+	if err == nil {
+		return
+	}
+	l.log(zapcore.ErrorLevel, err.Error(), append(errorFields(err), keysAndValues...))
+}
+
+// errorFields returns the fields describing err and its wrap chain.
+func errorFields(err error) []interface{} {
+	fields := []interface{}{zap.String("error", err.Error())}
+	if cause := rootCause(err); cause != err {
+		fields = append(fields, zap.String("cause", cause.Error()))
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		fields = append(fields, zap.String("code", coder.Code()))
+	}
+	return fields
+}
+
+// rootCause returns the deepest error found by repeatedly calling
+// errors.Unwrap on err.
+func rootCause(err error) error {
+	for {
+		next := errors.Unwrap(err)
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// LogErr logs msg at ErrorLevel with err attached as ErrorErr does, and
+// returns err, so a failure can be logged and returned in one call:
+//
+//	return l.LogErr(err, "failed to save")
+//
+// When err is nil nothing is logged and nil is returned.
+func (l *Logger) LogErr(err error, msg string, keysAndValues ...interface{}) error {
+	if err == nil {
+		return nil
+	}
+	l.log(zapcore.ErrorLevel, msg, append(errorFields(err), keysAndValues...))
+	return err
+}
+
+// LogRecover logs r, the value returned by recover(), at ErrorLevel with the
+// "panic recovered" message, the panic value under "panic" and the stack of
+// the panicking goroutine, from the frame that panicked, under "stacktrace".
+// It does nothing when r is nil, so it can be deferred anywhere:
+//
+//	defer func() { l.LogRecover(recover()) }()
+func (l *Logger) LogRecover(r interface{}) {
+	if r == nil {
+		return
+	}
+	l.logPanic(r, zap.String("stacktrace", panicStack()))
+}
+
+// logPanic is like log for the recovered panic r, with its stack attached
+// instead of the stacktrace of the log site.
+func (l *Logger) logPanic(r interface{}, stack zap.Field) {
+	out := l.output().WithOptions(zap.AddStacktrace(zapcore.InvalidLevel))
+	if ce := out.Check(zapcore.ErrorLevel, "panic recovered"); ce != nil {
+		ce.Write(append(l.logFromContext(l.Context), zap.Any("panic", r), stack)...)
+	}
+}
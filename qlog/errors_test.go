@@ -0,0 +1,133 @@
+package qlog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// codeError is an error implementing Coder.
+type codeError struct {
+	code string
+}
+
+func (e codeError) Error() string { return "code " + e.code }
+func (e codeError) Code() string  { return e.code }
+
+func TestErrorErr(t *testing.T) {
+	root := errors.New("connection refused")
+	tests := []struct {
+		name string
+		err  error
+		want map[string]interface{}
+	}{
+		{
+			name: "plain",
+			err:  root,
+			want: map[string]interface{}{"error": "connection refused"},
+		},
+		{
+			name: "wrapped",
+			err:  fmt.Errorf("save order: %w", fmt.Errorf("insert: %w", root)),
+			want: map[string]interface{}{
+				"error": "save order: insert: connection refused",
+				"cause": "connection refused",
+			},
+		},
+		{
+			name: "coder",
+			err:  fmt.Errorf("pay: %w", codeError{"E42"}),
+			want: map[string]interface{}{"error": "pay: code E42", "cause": "code E42", "code": "E42"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			l.ErrorErr(tt.err)
+
+			entry := logs.All()[0]
+			if entry.Message != tt.err.Error() {
+				t.Errorf("message = %q, want %q", entry.Message, tt.err.Error())
+			}
+			if got := entry.ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestErrorErrNil(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.ErrorErr(nil)
+
+	if n := logs.Len(); n != 0 {
+		t.Errorf("logged %d entries, want none", n)
+	}
+}
+
+func TestLogErr(t *testing.T) {
+	l, logs := NewObserved(nil)
+	err := fmt.Errorf("save order: %w", codeError{"E42"})
+	if got := l.LogErr(err, "failed to save", "order_id", "o-1"); got != err {
+		t.Errorf("LogErr() = %v, want %v", got, err)
+	}
+	if got := l.LogErr(nil, "not logged"); got != nil {
+		t.Errorf("LogErr(nil) = %v, want nil", got)
+	}
+
+	if n := logs.Len(); n != 1 {
+		t.Fatalf("logged %d entries, want 1", n)
+	}
+	entry := logs.All()[0]
+	if entry.Message != "failed to save" || entry.Level != zapcore.ErrorLevel {
+		t.Errorf("entry = %q at %v, want failed to save at error", entry.Message, entry.Level)
+	}
+	want := map[string]interface{}{
+		"error":    "save order: code E42",
+		"cause":    "code E42",
+		"code":     "E42",
+		"order_id": "o-1",
+	}
+	if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	if !strings.HasSuffix(entry.Caller.File, "errors_test.go") {
+		t.Errorf("caller = %s, want the LogErr call site", entry.Caller)
+	}
+}
+
+func TestLogRecover(t *testing.T) {
+	l, logs := NewObserved(nil)
+	func() {
+		defer func() { l.LogRecover(recover()) }()
+		panicOrder()
+	}()
+	l.LogRecover(nil)
+
+	if n := logs.Len(); n != 1 {
+		t.Fatalf("logged %d entries, want 1", n)
+	}
+	entry := logs.All()[0]
+	if entry.Message != "panic recovered" || entry.Level != zapcore.ErrorLevel {
+		t.Errorf("entry = %q at %v, want panic recovered at error", entry.Message, entry.Level)
+	}
+	fields := entry.ContextMap()
+	if fields["panic"] != "nil order" {
+		t.Errorf("panic = %v, want nil order", fields["panic"])
+	}
+	stack, _ := fields["stacktrace"].(string)
+	if !strings.HasPrefix(stack, "github.com/correctinho/correct-mlt-go/qlog.panicOrder") {
+		t.Errorf("stacktrace = %q, want it to start at the panicking frame", stack)
+	}
+	if entry.Stack != "" {
+		t.Errorf("entry has the stacktrace of the log site:\n%s", entry.Stack)
+	}
+}
+
+func panicOrder() {
+	panic("nil order")
+}
@@ -0,0 +1,172 @@
+package qlog
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"github.com/gin-gonic/gin"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Extractor derives the logging fields of a request context, such as a
+// framework's request type.
+type Extractor interface {
+	Extract(ctx interface{}) []zap.Field
+}
+
+// ExtractorFunc adapts an ordinary function to an Extractor.
+type ExtractorFunc func(ctx interface{}) []zap.Field
+
+// Extract calls f(ctx).
+func (f ExtractorFunc) Extract(ctx interface{}) []zap.Field {
+	return f(ctx)
+}
+
+// registeredExtractor is an Extractor with the matcher selecting its contexts.
+type registeredExtractor struct {
+	match     func(interface{}) bool
+	extractor Extractor
+}
+
+// builtinExtractors are the extractors of the supported frameworks. A
+// *gin.Context and a *fasthttp.RequestCtx are also a context.Context, so the
+// context.Context extractor comes last.
+var builtinExtractors = []registeredExtractor{
+	{isType[*gin.Context], ExtractorFunc(ginFields)},
+	{isType[*http.Request], ExtractorFunc(httpRequestFields)},
+	{isType[*fasthttp.RequestCtx], ExtractorFunc(fasthttpFields)},
+	{isType[echoContext], ExtractorFunc(echoFields)},
+	{isType[events.APIGatewayProxyRequestContext], ExtractorFunc(apiGatewayFields)},
+	{isType[*events.APIGatewayProxyRequestContext], ExtractorFunc(apiGatewayFields)},
+	{isType[context.Context], ExtractorFunc(contextFields)},
+}
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []registeredExtractor
+)
+
+// RegisterExtractor registers e to derive the logging fields of the contexts
+// for which match returns true, which lets qlog support other frameworks.
+// Only the first matching extractor is used; the registered ones are tried
+// from the most recently registered and before the built-in ones, so they can
+// also replace them.
+func RegisterExtractor(match func(interface{}) bool, e Extractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+	extractors = append(extractors, registeredExtractor{match, e})
+}
+
+// isType reports whether ctx is a T.
+func isType[T any](ctx interface{}) bool {
+	_, ok := ctx.(T)
+	return ok
+}
+
+// findExtractor returns the extractor for ctx, or nil if none matches.
+func findExtractor(ctx interface{}) Extractor {
+	extractorsMu.RLock()
+	registered := extractors
+	extractorsMu.RUnlock()
+	for i := len(registered) - 1; i >= 0; i-- {
+		if registered[i].match(ctx) {
+			return registered[i].extractor
+		}
+	}
+	for _, re := range builtinExtractors {
+		if re.match(ctx) {
+			return re.extractor
+		}
+	}
+	return nil
+}
+
+// logFromContext derives the logging fields for ctx with its extractor,
+// nested under "request" for a Logger returned by WithRequestNamespace. The
+// service fields read from SERVICE_NAME and SERVICE_VERSION are added once,
+// whatever the context type. A nil ctx, or a nil pointer, only gets the
+// service fields.
+func (l *Logger) logFromContext(ctx interface{}) (fields []zap.Field) {
+	if !isNil(ctx) {
+		if e := findExtractor(ctx); e != nil {
+			fields = e.Extract(ctx)
+		}
+	}
+	if l.requestNamespace && len(fields) > 0 {
+		if l.sensitive != nil {
+			// The masking core only sees the top-level fields.
+			fields = l.sensitive.mask(fields)
+		}
+		fields = []zap.Field{zap.Object(requestNamespace, fieldsObject(fields))}
+	}
+
+	if service, ok := os.LookupEnv("SERVICE_NAME"); ok {
+		fields = append(fields, zap.String(KeyService, service))
+	}
+	if version, ok := os.LookupEnv("SERVICE_VERSION"); ok && !stg.IsEmpty(&version) {
+		fields = append(fields, zap.String(KeyServiceVersion, version))
+	}
+	if l.newRelic {
+		fields = append(fields, newRelicFields(stdContext(ctx))...)
+	}
+	return fields
+}
+
+// isNil reports whether ctx is nil or a nil pointer.
+func isNil(ctx interface{}) bool {
+	if ctx == nil {
+		return true
+	}
+	v := reflect.ValueOf(ctx)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// stdContext returns the context.Context of the request context ctx, or nil
+// when it has none.
+func stdContext(ctx interface{}) context.Context {
+	if isNil(ctx) {
+		return nil
+	}
+	switch value := ctx.(type) {
+	case *gin.Context:
+		if value.Request != nil {
+			return value.Request.Context()
+		}
+	case *http.Request:
+		return value.Context()
+	case context.Context:
+		return value
+	}
+	return nil
+}
+
+// requestNamespace is the field the request fields are nested under by a
+// Logger returned by WithRequestNamespace.
+const requestNamespace = "request"
+
+// WithRequestNamespace returns a child logger nesting the fields derived from
+// Context under a "request" object, e.g. {"request":{"x-request-id":"..."}},
+// instead of at the top level. The service fields and the other fields are not
+// affected.
+func (l *Logger) WithRequestNamespace() *Logger {
+	child := *l
+	child.requestNamespace = true
+	return &child
+}
+
+// fieldsObject marshals fields as the members of a JSON object.
+type fieldsObject []zap.Field
+
+func (fs fieldsObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range fs {
+		f.AddTo(enc)
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+func TestServiceFields(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "payments")
+	t.Setenv("SERVICE_VERSION", "1.2.3")
+	contexts := []struct {
+		name string
+		ctx  interface{}
+	}{
+		{"nil", nil},
+		{"context.Context", context.Background()},
+		{"*http.Request", httptest.NewRequest("GET", "/", nil)},
+		{"*gin.Context", newGinContext(httptest.NewRequest("GET", "/", nil))},
+		{"*fasthttp.RequestCtx", &fasthttp.RequestCtx{}},
+		{"unknown", struct{}{}},
+	}
+	for _, tt := range contexts {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(tt.ctx)
+			l.Info("hello")
+
+			fields := logs.All()[0].ContextMap()
+			if fields[KeyService] != "payments" || fields[KeyServiceVersion] != "1.2.3" {
+				t.Errorf("fields = %v, want the service name and version", fields)
+			}
+		})
+	}
+}
+
+func TestServiceFieldsUnset(t *testing.T) {
+	t.Setenv("SERVICE_VERSION", "")
+	l, logs := NewObserved(nil)
+	l.Info("hello")
+
+	if _, ok := logs.All()[0].ContextMap()[KeyServiceVersion]; ok {
+		t.Errorf("%s logged while SERVICE_VERSION is empty", KeyServiceVersion)
+	}
+}
+
+// tenantContext is a request context type unknown to qlog.
+type tenantContext struct {
+	tenant string
+}
+
+// restoreExtractors undoes the RegisterExtractor calls of the test.
+func restoreExtractors(t *testing.T) {
+	extractorsMu.RLock()
+	saved := extractors
+	extractorsMu.RUnlock()
+	t.Cleanup(func() {
+		extractorsMu.Lock()
+		extractors = saved
+		extractorsMu.Unlock()
+	})
+}
+
+func TestRegisterExtractor(t *testing.T) {
+	restoreExtractors(t)
+	RegisterExtractor(isType[tenantContext], ExtractorFunc(func(ctx interface{}) []zap.Field {
+		return []zap.Field{zap.String("tenant", ctx.(tenantContext).tenant)}
+	}))
+
+	l, logs := NewObserved(tenantContext{"acme"})
+	l.Info("hello")
+	if got := logs.All()[0].ContextMap()["tenant"]; got != "acme" {
+		t.Errorf("tenant = %v, want acme", got)
+	}
+}
+
+func TestRegisterExtractorOverride(t *testing.T) {
+	restoreExtractors(t)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ContextWithRequestID(context.Background(), "req-1"))
+	RegisterExtractor(isType[*http.Request], ExtractorFunc(func(interface{}) []zap.Field {
+		return []zap.Field{zap.String("first", "1")}
+	}))
+	RegisterExtractor(isType[*http.Request], ExtractorFunc(func(interface{}) []zap.Field {
+		return []zap.Field{zap.String("latest", "1")}
+	}))
+
+	want := map[string]interface{}{"latest": "1"}
+	if got := fieldMap(NewNop().WithContext(req).Fields()); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestNilContext(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "payments")
+	contexts := []struct {
+		name string
+		ctx  interface{}
+	}{
+		{"nil", nil},
+		{"*gin.Context", (*gin.Context)(nil)},
+		{"*http.Request", (*http.Request)(nil)},
+		{"*fasthttp.RequestCtx", (*fasthttp.RequestCtx)(nil)},
+		{"*gin.Context without request", newGinContext(nil)},
+	}
+	for _, tt := range contexts {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(tt.ctx)
+			l.Info("info", "k", "v")
+			l.InfoJSON("json", `{"id":1}`, LoggerExtras{})
+			l.InfoFields("fields")
+			l.InfoCtx(nil, "ctx")
+			l.Audit("audit")
+			l.DebugEnabled()
+
+			if n := logs.Len(); n != 5 {
+				t.Fatalf("logged %d entries, want 5", n)
+			}
+			for _, entry := range logs.All() {
+				if got := entry.ContextMap()[KeyService]; got != "payments" {
+					t.Errorf("%s: %s = %v, want payments", entry.Message, KeyService, got)
+				}
+			}
+		})
+	}
+}
+
+func TestWithNamespace(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("SERVICE_NAME", "orders")
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	tests := []struct {
+		name string
+		log  func(*Logger)
+		want map[string]interface{}
+	}{
+		{
+			name: "namespace",
+			log: func(l *Logger) {
+				l.With(zap.String("tenant", "acme")).WithNamespace("order").With(zap.String("id", "o-1")).Info("paid", "amount", 10)
+			},
+			want: map[string]interface{}{
+				"tenant": "acme",
+				"order": map[string]interface{}{
+					"id":          "o-1",
+					"amount":      10.0,
+					KeyXRequestID: "req-1",
+					KeyService:    "orders",
+				},
+			},
+		},
+		{
+			name: "request namespace",
+			log: func(l *Logger) {
+				l.WithRequestNamespace().Info("paid", "amount", 10)
+			},
+			want: map[string]interface{}{
+				"request":  map[string]interface{}{KeyXRequestID: "req-1"},
+				"amount":   10.0,
+				KeyService: "orders",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(NewProductionWithWriter(&buf, ctx))
+
+			entries := decodeEntries(t, buf.String())
+			if len(entries) != 1 {
+				t.Fatalf("logged %d entries, want 1: %s", len(entries), buf.String())
+			}
+			entry := entries[0]
+			for _, k := range []string{"level", "ts", "caller", "message"} {
+				delete(entry, k)
+			}
+			if !reflect.DeepEqual(entry, tt.want) {
+				t.Errorf("fields = %v, want %v", entry, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package qlog
+
+import (
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"github.com/valyala/fasthttp"
+	"go.uber.org/zap"
+)
+
+// RequestID wraps a fasthttp handler so every request has a request id: the
+// X-Request-ID header when present or a new UUID otherwise. The id is stored
+// with ctx.SetUserValue("request_id", id), where logFromContext reads it, and
+// echoed back in the X-Request-ID response header.
+func RequestID(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(headerXRequestID))
+		if stg.IsEmpty(&id) {
+			id = newRequestID()
+		}
+		ctx.SetUserValue("request_id", id)
+		ctx.Response.Header.Set(headerXRequestID, id)
+		next(ctx)
+	}
+}
+
+// fasthttpFields is the Extractor of *fasthttp.RequestCtx.
+func fasthttpFields(ctx interface{}) (fields []zap.Field) {
+	value := ctx.(*fasthttp.RequestCtx)
+	uuid, _ := value.UserValue("request_id").(string)
+	if stg.IsEmpty(&uuid) {
+		// Routers not storing the request id still get the header one.
+		uuid = string(value.Request.Header.Peek(headerXRequestID))
+	}
+	if !stg.IsEmpty(&uuid) {
+		fields = append(fields, zap.String(KeyXRequestID, uuid))
+	}
+	protocol := string(value.URI().Scheme())
+	if stg.IsEmpty(&protocol) {
+		protocol = string(value.Request.Header.Protocol())
+	}
+	if !stg.IsEmpty(&protocol) {
+		fields = append(fields, zap.String(KeyProtocol, protocol))
+	}
+	if host := string(value.Host()); !stg.IsEmpty(&host) {
+		fields = append(fields, zap.String(KeyDomainName, host))
+	}
+	if uri := string(value.RequestURI()); !stg.IsEmpty(&uri) {
+		fields = append(fields, zap.String(KeyRequestURI, uri))
+	}
+	return fields
+}
@@ -0,0 +1,91 @@
+package qlog
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"header", "req-1"},
+		{"generated", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			if tt.header != "" {
+				ctx.Request.Header.Set(headerXRequestID, tt.header)
+			}
+			var logged interface{}
+			RequestID(func(ctx *fasthttp.RequestCtx) {
+				logged = fieldMap(NewNop().WithContext(ctx).Fields())[KeyXRequestID]
+			})(ctx)
+
+			id := string(ctx.Response.Header.Peek(headerXRequestID))
+			if tt.header != "" && id != tt.header {
+				t.Errorf("request id = %q, want %q", id, tt.header)
+			}
+			if tt.header == "" && !uuidPattern.MatchString(id) {
+				t.Errorf("request id = %q, want a UUID", id)
+			}
+			if logged != id {
+				t.Errorf("logged %s = %v, want %q", KeyXRequestID, logged, id)
+			}
+		})
+	}
+}
+
+func TestFasthttpFields(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		host string
+		want map[string]interface{}
+	}{
+		{
+			name: "absolute uri",
+			uri:  "https://api.example.com/orders?id=1",
+			want: map[string]interface{}{KeyProtocol: "https", KeyDomainName: "api.example.com", KeyRequestURI: "https://api.example.com/orders?id=1"},
+		},
+		{
+			name: "host header",
+			uri:  "/orders",
+			host: "tenant.example.com",
+			want: map[string]interface{}{KeyProtocol: "http", KeyDomainName: "tenant.example.com", KeyRequestURI: "/orders"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &fasthttp.RequestCtx{}
+			ctx.Request.SetRequestURI(tt.uri)
+			if tt.host != "" {
+				ctx.Request.Header.SetHost(tt.host)
+			}
+			got := fieldMap(fasthttpFields(ctx))
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %v", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestFasthttpFieldsRequestIDHeader(t *testing.T) {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.Set(headerXRequestID, "from-header")
+	if got := fieldMap(fasthttpFields(ctx))[KeyXRequestID]; got != "from-header" {
+		t.Errorf("%s = %v, want from-header", KeyXRequestID, got)
+	}
+	ctx.SetUserValue("request_id", "from-router")
+	if got := fieldMap(fasthttpFields(ctx))[KeyXRequestID]; got != "from-router" {
+		t.Errorf("%s = %v, want from-router", KeyXRequestID, got)
+	}
+}
@@ -0,0 +1,82 @@
+package qlog
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Duration constructs a field logging d as floating-point milliseconds, e.g.
+// 1500 for 1.5s, instead of the integer nanoseconds of zap.Duration. The
+// key/value API logs time.Duration values the same way.
+func Duration(key string, d time.Duration) zap.Field {
+	return zap.Float64(key, float64(d)/float64(time.Millisecond))
+}
+
+// Amount constructs a field logging a money amount as a nested object, e.g.
+// {"cents":-1990,"currency":"BRL"}, so it keeps its precision and can be
+// aggregated downstream instead of being parsed back from a string.
+func Amount(key string, cents int64, currency string) zap.Field {
+	return zap.Object(key, amount{cents: cents, currency: currency})
+}
+
+// amount is the value logged by Amount.
+type amount struct {
+	cents    int64
+	currency string
+}
+
+func (a amount) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt64("cents", a.cents)
+	enc.AddString("currency", a.currency)
+	return nil
+}
+
+// decimal is implemented by arbitrary-precision decimal types, such as
+// github.com/shopspring/decimal.Decimal. The key/value API logs their value
+// as a JSON number with all its digits instead of a string.
+type decimal interface {
+	String() string
+	InexactFloat64() float64
+}
+
+// decimalField constructs the field of the decimal d.
+func decimalField(key string, d decimal) zap.Field {
+	s := d.String()
+	if !json.Valid([]byte(s)) {
+		return zap.String(key, s)
+	}
+	return zap.Reflect(key, json.Number(s))
+}
+
+// keyBodySHA256 is the key of the field logged by BodyHash.
+const keyBodySHA256 = "body_sha256"
+
+// BodyHash constructs a field logging the hex SHA-256 of a request body b
+// under "body_sha256", to spot duplicate submissions without logging the
+// body, which may hold personal data.
+func BodyHash(b []byte) zap.Field {
+	return zap.String(keyBodySHA256, bodySHA256(b))
+}
+
+// bodySHA256 returns the hex SHA-256 of b.
+func bodySHA256(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// anyField constructs the field of a key/value pair, like zap.Any except for
+// the types qlog encodes its own way.
+func anyField(key string, value interface{}) zap.Field {
+	switch v := value.(type) {
+	case time.Duration:
+		return Duration(key, v)
+	case decimal:
+		return decimalField(key, v)
+	}
+	return zap.Any(key, value)
+}
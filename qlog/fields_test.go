@@ -0,0 +1,57 @@
+package qlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want float64
+	}{
+		{1500 * time.Millisecond, 1500},
+		{250 * time.Microsecond, 0.25},
+		{0, 0},
+		{-2 * time.Millisecond, -2},
+	}
+	for _, tt := range tests {
+		if got := fieldMap([]zap.Field{Duration("d", tt.d)})["d"]; got != tt.want {
+			t.Errorf("Duration(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+}
+
+// fakeDecimal implements decimal like shopspring/decimal.Decimal does.
+type fakeDecimal string
+
+func (d fakeDecimal) String() string          { return string(d) }
+func (d fakeDecimal) InexactFloat64() float64 { return 0 }
+
+func TestAmountAndDecimal(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var buf bytes.Buffer
+	l := NewProductionWithWriter(&buf, nil)
+	l.Info("refund", Amount("amount", -1990, "BRL"),
+		"price", fakeDecimal("12345678901234567890.123456789"),
+		"rate", fakeDecimal("NaN"))
+
+	var entry map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("decode %q: %v", buf.String(), err)
+	}
+	want := map[string]string{
+		"amount": `{"cents":-1990,"currency":"BRL"}`,
+		"price":  `12345678901234567890.123456789`,
+		"rate":   `"NaN"`,
+	}
+	for k, v := range want {
+		if got := string(entry[k]); got != v {
+			t.Errorf("%s = %s, want %s", k, got, v)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package qlog
+
+import (
+	"os"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// gelfVersion is the GELF specification version of the entries.
+const gelfVersion = "1.1"
+
+// gelfLevels maps zap levels to the syslog severities used by GELF.
+var gelfLevels = map[zapcore.Level]int64{
+	zapcore.DebugLevel:  7,
+	zapcore.InfoLevel:   6,
+	zapcore.WarnLevel:   4,
+	zapcore.ErrorLevel:  3,
+	zapcore.DPanicLevel: 2,
+	zapcore.PanicLevel:  2,
+	zapcore.FatalLevel:  1,
+}
+
+// NewProductionGELF builds a Logger writing to standard error entries in the
+// Graylog Extended Log Format: a JSON object with the GELF version, the host
+// name, the message as short_message, the syslog severity as level and every
+// other field, including the ones derived from context, prefixed with "_".
+func NewProductionGELF(context interface{}) *Logger {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		MessageKey:     "short_message",
+		LevelKey:       "level",
+		TimeKey:        "timestamp",
+		NameKey:        "_logger",
+		CallerKey:      "_caller",
+		StacktraceKey:  "full_message",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    gelfLevelEncoder,
+		EncodeTime:     zapcore.EpochTimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+	hostname, _ := os.Hostname()
+	enc.AddString("version", gelfVersion)
+	enc.AddString("host", hostname)
+
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	core := zapcore.NewCore(enc, zapcore.Lock(os.Stderr), level)
+	return newLogger(context, &transformCore{Core: core, transform: gelfFields}, level)
+}
+
+// gelfLevelEncoder encodes a level as its syslog severity.
+func gelfLevelEncoder(lvl zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	enc.AppendInt64(gelfLevels[lvl])
+}
+
+// gelfFields prefixes the field keys with "_", as GELF requires for
+// additional fields.
+func gelfFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		if !strings.HasPrefix(f.Key, "_") {
+			f.Key = "_" + f.Key
+		}
+		out[i] = f
+	}
+	return out
+}
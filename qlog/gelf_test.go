@@ -0,0 +1,38 @@
+package qlog
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewProductionGELF(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	out := captureStderr(t, func() {
+		l := NewProductionGELF(ContextWithRequestID(context.Background(), "req-1"))
+		l.With(zap.String("tenant", "acme")).Warn("hello", "user_id", 1, "_raw", "kept")
+	})
+
+	entry := decodeEntries(t, out)[0]
+	host, _ := os.Hostname()
+	want := map[string]interface{}{
+		"version":           gelfVersion,
+		"host":              host,
+		"short_message":     "hello",
+		"level":             4.0,
+		"_" + KeyXRequestID: "req-1",
+		"_tenant":           "acme",
+		"_user_id":          1.0,
+		"_raw":              "kept",
+	}
+	for k, v := range want {
+		if entry[k] != v {
+			t.Errorf("%s = %v, want %v", k, entry[k], v)
+		}
+	}
+	if _, ok := entry["timestamp"].(float64); !ok {
+		t.Errorf("timestamp = %v, want epoch seconds", entry["timestamp"])
+	}
+}
@@ -0,0 +1,300 @@
+package qlog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Recovery returns a gin middleware that recovers from any panic raised by the
+// next handlers, logs it at ErrorLevel as LogRecover does, with the fields
+// derived from the *gin.Context, and responds with HTTP 500.
+func Recovery() gin.HandlerFunc {
+	base := NewProduction(nil)
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, err interface{}) {
+		base.WithContext(c).LogRecover(err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+	})
+}
+
+// GinLogger returns a gin middleware that logs an access entry for every
+// request once it has been handled, with its method, URI, route template,
+// e.g. "/users/:id", status, client IP, latency, content length and user agent
+// along with the fields derived from the *gin.Context. Requests matching no
+// route are logged with the "<unmatched>" route. Requests answered with a 5xx
+// status are logged at ErrorLevel, the others at InfoLevel.
+func GinLogger() gin.HandlerFunc {
+	base := NewProduction(nil)
+	// The stacktrace of an access entry would only show the middleware.
+	base.Zap = base.Zap.WithOptions(zap.AddStacktrace(zapcore.InvalidLevel))
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		lvl := zapcore.InfoLevel
+		if status >= http.StatusInternalServerError {
+			lvl = zapcore.ErrorLevel
+		}
+		l := base.WithContext(c)
+		ce := l.output().Check(lvl, "request completed")
+		if ce == nil {
+			return
+		}
+		ce.Write(mergeFields(l.logFromContext(c), append([]zap.Field{
+			zap.String("method", c.Request.Method),
+			zap.String(KeyRequestURI, c.Request.URL.RequestURI()),
+			zap.String("route", ginRoute(c)),
+			zap.Int("status", status),
+			zap.String(KeySourceIP, c.ClientIP()),
+			zap.Duration("latency", time.Since(start)),
+		}, RequestHeaderFields(c.Request)...))...)
+	}
+}
+
+// unmatchedRoute is the route logged by GinLogger for the requests matching
+// no route.
+const unmatchedRoute = "<unmatched>"
+
+// ginRoute returns the template of the route matched by c, or unmatchedRoute.
+func ginRoute(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return unmatchedRoute
+}
+
+// defaultBodyHashLimit is the size of the largest body hashed by GinBodyHash.
+const defaultBodyHashLimit = 1 << 20
+
+// GinBodyHash returns a gin middleware computing the SHA-256 of the body of
+// the mutating requests, POST, PUT, PATCH and DELETE, and storing it on the
+// *gin.Context so the entries logged for the request carry it under
+// "body_sha256". It helps spotting duplicate submissions without logging the
+// body itself. The body is restored and can still be read by the handlers.
+// Bodies larger than 1 MiB are not hashed, see GinBodyHashWithLimit.
+func GinBodyHash() gin.HandlerFunc {
+	return GinBodyHashWithLimit(defaultBodyHashLimit)
+}
+
+// GinBodyHashWithLimit is like GinBodyHash but hashes the bodies of at most
+// limit bytes. At most limit+1 bytes are buffered: a larger body, or one that
+// fails to be read, is not hashed and the handlers read it as if it had not
+// been touched, including the read error.
+func GinBodyHashWithLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			if sum, ok := hashBody(c.Request, limit); ok {
+				c.Set(keyBodySHA256, sum)
+			}
+		}
+		c.Next()
+	}
+}
+
+// hashBody returns the hex SHA-256 of the body of r when it holds at most
+// limit bytes. r.Body is replaced by a reader returning the same bytes,
+// followed by the rest of a larger body or by the read error.
+func hashBody(r *http.Request, limit int64) (string, bool) {
+	body := r.Body
+	read, err := io.ReadAll(io.LimitReader(body, limit+1))
+	switch {
+	case err != nil:
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(read), errReader{err}), body}
+		return "", false
+	case int64(len(read)) > limit:
+		r.Body = readCloser{io.MultiReader(bytes.NewReader(read), body), body}
+		return "", false
+	}
+	_ = body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(read))
+	return bodySHA256(read), true
+}
+
+// readCloser combines a Reader and the Closer of the body it replaces.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// errReader is an io.Reader returning err.
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// ginFields is the Extractor of *gin.Context.
+func ginFields(ctx interface{}) (fields []zap.Field) {
+	c := ctx.(*gin.Context)
+	if uuid := ginRequestID(c); !stg.IsEmpty(&uuid) {
+		fields = append(fields, zap.String(KeyXRequestID, uuid))
+	}
+	for _, gk := range ginKeys {
+		if v := ginValue(c, gk.key, gk.header); !stg.IsEmpty(&v) {
+			fields = append(fields, zap.String(gk.key, v))
+		}
+	}
+	if account := ginAccount(c); !stg.IsEmpty(&account) {
+		fields = append(fields, zap.String(KeyAccount, account))
+	}
+	fields = append(fields, ginContextValues(c)...)
+	if c.Request != nil {
+		if prefix := domainPrefix(c.Request.Host); !stg.IsEmpty(&prefix) {
+			fields = append(fields, zap.String(KeyDomainPrefix, prefix))
+		}
+	}
+	return fields
+}
+
+// RequestIDSource is a place where the request id of a *gin.Context is looked
+// up: a gin context key or, when Key is empty, a request header.
+type RequestIDSource struct {
+	Key    string
+	Header string
+}
+
+// GinRequestIDSources is the order in which the request id of a *gin.Context
+// is looked up; the first non-empty value is logged under KeyXRequestID.
+var GinRequestIDSources = []RequestIDSource{
+	{Key: "request_id"},
+	{Key: "x-request-id"},
+	{Header: "X-Request-ID"},
+	{Header: "X-Correlation-ID"},
+}
+
+// ginRequestID returns the first non-empty request id of c found in
+// GinRequestIDSources.
+func ginRequestID(c *gin.Context) string {
+	for _, src := range GinRequestIDSources {
+		var id string
+		switch {
+		case src.Key != "":
+			id = c.GetString(src.Key)
+		case c.Request != nil:
+			id = c.Request.Header.Get(src.Header)
+		}
+		if !stg.IsEmpty(&id) {
+			return id
+		}
+	}
+	return ""
+}
+
+// ginContextField maps a gin context key to the field its value is logged
+// under.
+type ginContextField struct {
+	key   string
+	field string
+}
+
+var (
+	ginContextFieldsMu sync.RWMutex
+	ginContextFields   = []ginContextField{
+		{"user_id", "user_id"},
+		{keyBodySHA256, keyBodySHA256},
+		{KeySessionID, KeySessionID},
+	}
+)
+
+// RegisterGinContextField makes the value set on a *gin.Context under key,
+// e.g. with c.Set(key, v) by an auth middleware, be logged under field. The
+// "user_id" key and the "body_sha256" and "session_id" keys set by GinBodyHash
+// and GinSessionID are logged by default under the same name. Registering a key
+// again changes its field.
+func RegisterGinContextField(key, field string) {
+	ginContextFieldsMu.Lock()
+	defer ginContextFieldsMu.Unlock()
+	for i, f := range ginContextFields {
+		if f.key == key {
+			ginContextFields[i].field = field
+			return
+		}
+	}
+	ginContextFields = append(ginContextFields, ginContextField{key, field})
+}
+
+// ginContextValues returns the fields of the registered gin context keys set
+// on c.
+func ginContextValues(c *gin.Context) (fields []zap.Field) {
+	ginContextFieldsMu.RLock()
+	defer ginContextFieldsMu.RUnlock()
+	for _, f := range ginContextFields {
+		v, ok := c.Get(f.key)
+		if !ok || v == nil || v == "" {
+			continue
+		}
+		fields = append(fields, anyField(f.field, v))
+	}
+	return fields
+}
+
+// subjectClaims is implemented by parsed JWT claims exposing their subject,
+// such as the claims types of github.com/golang-jwt/jwt/v5.
+type subjectClaims interface {
+	GetSubject() (string, error)
+}
+
+// ginAccount returns the account set on c by the auth middleware under the
+// "account" key or, when absent, the subject of the JWT claims stored under
+// the "claims" key.
+func ginAccount(c *gin.Context) string {
+	if account := c.GetString("account"); !stg.IsEmpty(&account) {
+		return account
+	}
+	value, _ := c.Get("claims")
+	switch claims := value.(type) {
+	case subjectClaims:
+		sub, _ := claims.GetSubject()
+		return sub
+	case map[string]interface{}:
+		sub, _ := claims["sub"].(string)
+		return sub
+	}
+	return ""
+}
+
+// ginKeys maps the logging keys read from a *gin.Context to the request
+// header used as fallback when the key was not set on the context.
+var ginKeys = []struct {
+	key    string
+	header string
+}{
+	{KeyAPIRequestID, "X-Amzn-RequestId"},
+	{KeyDomainName, "X-Forwarded-Host"},
+	{KeySourceIP, "X-Forwarded-For"},
+	{KeyProtocol, "X-Forwarded-Proto"},
+}
+
+// ginValue returns the value stored on the gin context under key or, when
+// absent, the value of the given request header. For X-Forwarded-For only the
+// first (client) address is returned.
+func ginValue(c *gin.Context, key, header string) string {
+	if v := c.GetString(key); !stg.IsEmpty(&v) {
+		return v
+	}
+	if c.Request == nil {
+		return ""
+	}
+	v := c.Request.Header.Get(header)
+	if header == "X-Forwarded-For" {
+		v, _, _ = strings.Cut(v, ",")
+	}
+	return strings.TrimSpace(v)
+}
@@ -0,0 +1,359 @@
+package qlog
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGinFieldsKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]string
+		headers map[string]string
+		want    map[string]string
+	}{
+		{
+			name: "context keys",
+			set: map[string]string{
+				KeyAPIRequestID: "req-1",
+				KeyDomainName:   "api.example.com",
+				KeySourceIP:     "10.0.0.1",
+			},
+			want: map[string]string{
+				KeyAPIRequestID: "req-1",
+				KeyDomainName:   "api.example.com",
+				KeySourceIP:     "10.0.0.1",
+			},
+		},
+		{
+			name: "header fallback",
+			headers: map[string]string{
+				"X-Amzn-RequestId":  "req-2",
+				"X-Forwarded-Host":  "api.example.com",
+				"X-Forwarded-For":   "10.0.0.2, 10.0.0.3",
+				"X-Forwarded-Proto": "https",
+			},
+			want: map[string]string{
+				KeyAPIRequestID: "req-2",
+				KeyDomainName:   "api.example.com",
+				KeySourceIP:     "10.0.0.2",
+				KeyProtocol:     "https",
+			},
+		},
+		{
+			name:    "context key wins over header",
+			set:     map[string]string{KeySourceIP: "10.0.0.1"},
+			headers: map[string]string{"X-Forwarded-For": "10.0.0.2"},
+			want:    map[string]string{KeySourceIP: "10.0.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			c := newGinContext(req)
+			for k, v := range tt.set {
+				c.Set(k, v)
+			}
+			got := fieldMap(ginFields(c))
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("%s = %v, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestGinFieldsSkipAPIKey(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Api-Key", "secret")
+	c := newGinContext(req)
+
+	if v, ok := fieldMap(ginFields(c))[KeyAPIKey]; ok {
+		t.Errorf("%s = %v, want it not logged", KeyAPIKey, v)
+	}
+}
+
+func TestRecovery(t *testing.T) {
+	rec := httptest.NewRecorder()
+	out := captureStderr(t, func() {
+		r := gin.New()
+		r.Use(Recovery())
+		r.GET("/boom", func(*gin.Context) { panic("boom") })
+		req := httptest.NewRequest("GET", "/boom", nil)
+		req.Header.Set("X-Request-Id", "req-1")
+		r.ServeHTTP(rec, req)
+	})
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", rec.Code)
+	}
+	entries := decodeEntries(t, out)
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1: %s", len(entries), out)
+	}
+	entry := entries[0]
+	if entry["level"] != "error" || entry["message"] != "panic recovered" || entry["panic"] != "boom" {
+		t.Errorf("entry = %v", entry)
+	}
+	if entry[KeyXRequestID] != "req-1" {
+		t.Errorf("%s = %v, want req-1", KeyXRequestID, entry[KeyXRequestID])
+	}
+	if n := strings.Count(out, `"stacktrace"`); n != 1 {
+		t.Errorf("entry has %d stacktraces, want 1", n)
+	}
+	stack, _ := entry["stacktrace"].(string)
+	if !strings.Contains(stack, "TestRecovery") {
+		t.Errorf("stacktrace doesn't show the panicking handler:\n%s", stack)
+	}
+}
+
+func TestGinRequestID(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     map[string]string
+		headers map[string]string
+		want    interface{}
+	}{
+		{"request_id key", map[string]string{"request_id": "a", "x-request-id": "b"}, map[string]string{"X-Request-ID": "c"}, "a"},
+		{"x-request-id key", map[string]string{"x-request-id": "b"}, map[string]string{"X-Request-ID": "c"}, "b"},
+		{"X-Request-ID header", nil, map[string]string{"X-Request-ID": "c", "X-Correlation-ID": "d"}, "c"},
+		{"X-Correlation-ID header", nil, map[string]string{"X-Correlation-ID": "d"}, "d"},
+		{"none", nil, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			c := newGinContext(req)
+			for k, v := range tt.set {
+				c.Set(k, v)
+			}
+			if got := fieldMap(ginFields(c))[KeyXRequestID]; got != tt.want {
+				t.Errorf("%s = %v, want %v", KeyXRequestID, got, tt.want)
+			}
+		})
+	}
+}
+
+// serveGin serves req with GinLogger and the given route, and returns the
+// access entry logged.
+func serveGin(t *testing.T, path string, h gin.HandlerFunc, req *http.Request) map[string]interface{} {
+	t.Helper()
+	t.Setenv("LOG_HOST_PID", "false")
+	out := captureStderr(t, func() {
+		r := gin.New()
+		r.Use(GinLogger())
+		r.GET(path, h)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	})
+	entries := decodeEntries(t, out)
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1: %s", len(entries), out)
+	}
+	return entries[0]
+}
+
+func TestGinLogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		wantLevel string
+	}{
+		{"ok", http.StatusOK, "info"},
+		{"not found", http.StatusNotFound, "info"},
+		{"server error", http.StatusBadGateway, "error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/orders?page=2", nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			entry := serveGin(t, "/orders", func(c *gin.Context) { c.Status(tt.status) }, req)
+
+			want := map[string]interface{}{
+				"level":       tt.wantLevel,
+				"message":     "request completed",
+				"method":      "GET",
+				KeyRequestURI: "/orders?page=2",
+				"status":      float64(tt.status),
+				KeySourceIP:   "192.0.2.1",
+				KeyXRequestID: "req-1",
+			}
+			for k, v := range want {
+				if entry[k] != v {
+					t.Errorf("%s = %v, want %v", k, entry[k], v)
+				}
+			}
+			if _, ok := entry["latency"].(float64); !ok {
+				t.Errorf("latency = %v, want a duration", entry["latency"])
+			}
+			if _, ok := entry["stacktrace"]; ok {
+				t.Error("access entry has a stacktrace")
+			}
+		})
+	}
+}
+
+// subjectOnly implements subjectClaims like the jwt/v5 claims types do.
+type subjectOnly string
+
+func (s subjectOnly) GetSubject() (string, error) { return string(s), nil }
+
+func TestGinAccount(t *testing.T) {
+	tests := []struct {
+		name string
+		set  map[string]interface{}
+		want interface{}
+	}{
+		{"account key", map[string]interface{}{"account": "acc-1", "claims": subjectOnly("sub-1")}, "acc-1"},
+		{"subject claims", map[string]interface{}{"claims": subjectOnly("sub-1")}, "sub-1"},
+		{"map claims", map[string]interface{}{"claims": map[string]interface{}{"sub": "sub-2"}}, "sub-2"},
+		{"map claims without sub", map[string]interface{}{"claims": map[string]interface{}{"iss": "x"}}, nil},
+		{"none", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newGinContext(httptest.NewRequest("GET", "/", nil))
+			for k, v := range tt.set {
+				c.Set(k, v)
+			}
+			if got := fieldMap(ginFields(c))[KeyAccount]; got != tt.want {
+				t.Errorf("%s = %v, want %v", KeyAccount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGinContextValues(t *testing.T) {
+	ginContextFieldsMu.RLock()
+	saved := append([]ginContextField(nil), ginContextFields...)
+	ginContextFieldsMu.RUnlock()
+	t.Cleanup(func() {
+		ginContextFieldsMu.Lock()
+		ginContextFields = saved
+		ginContextFieldsMu.Unlock()
+	})
+	RegisterGinContextField("tenant_id", "tenant")
+	RegisterGinContextField("org", "org_id")
+	RegisterGinContextField("org", "organization")
+
+	c := newGinContext(httptest.NewRequest("GET", "/", nil))
+	c.Set("user_id", 42)
+	c.Set("tenant_id", "acme")
+	c.Set("org", "o-1")
+	c.Set("empty", "")
+	got := fieldMap(ginFields(c))
+
+	want := map[string]interface{}{"user_id": int64(42), "tenant": "acme", "organization": "o-1"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %v", k, got[k], v)
+		}
+	}
+	if _, ok := got["org_id"]; ok {
+		t.Error("re-registered key still logged under its former field")
+	}
+}
+
+// failingReader returns data, then err.
+type failingReader struct {
+	data []byte
+	err  error
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, r.err
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestGinBodyHash(t *testing.T) {
+	errRead := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		method   string
+		body     io.Reader
+		wantHash bool
+		wantBody string
+		wantErr  error
+	}{
+		{"post", "POST", strings.NewReader(`{"id":1}`), true, `{"id":1}`, nil},
+		{"get", "GET", strings.NewReader(`{"id":1}`), false, `{"id":1}`, nil},
+		{"empty", "PUT", nil, false, "", nil},
+		{"over the limit", "PATCH", strings.NewReader(`{"id":12345}`), false, `{"id":12345}`, nil},
+		{"read error", "POST", &failingReader{data: []byte(`{"id"`), err: errRead}, false, `{"id"`, errRead},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				fields  map[string]interface{}
+				body    []byte
+				readErr error
+			)
+			r := gin.New()
+			r.Use(GinBodyHashWithLimit(10))
+			r.Handle(tt.method, "/orders", func(c *gin.Context) {
+				fields = fieldMap(ginFields(c))
+				body, readErr = io.ReadAll(c.Request.Body)
+			})
+			r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, "/orders", tt.body))
+
+			got, ok := fields[keyBodySHA256]
+			if ok != tt.wantHash {
+				t.Fatalf("%s = %v, want hashed %t", keyBodySHA256, got, tt.wantHash)
+			}
+			if ok && got != bodySHA256([]byte(tt.wantBody)) {
+				t.Errorf("%s = %v, want the SHA-256 of %s", keyBodySHA256, got, tt.wantBody)
+			}
+			if string(body) != tt.wantBody || !errors.Is(readErr, tt.wantErr) {
+				t.Errorf("handler read %q, %v, want %q, %v", body, readErr, tt.wantBody, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGinLoggerRequestHeaders(t *testing.T) {
+	req := httptest.NewRequest("GET", "/orders", nil)
+	req.Header.Set("User-Agent", "curl/8.5.0")
+	entry := serveGin(t, "/orders", func(c *gin.Context) { c.Status(http.StatusOK) }, req)
+
+	if entry["user_agent"] != "curl/8.5.0" {
+		t.Errorf("user_agent = %v, want curl/8.5.0", entry["user_agent"])
+	}
+	if _, ok := entry["content_length"]; ok {
+		t.Errorf("content_length = %v, want none for a request without body", entry["content_length"])
+	}
+}
+
+func TestGinLoggerRoute(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"matched", "/orders/42?expand=items", "/orders/:id"},
+		{"unmatched", "/missing", unmatchedRoute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := serveGin(t, "/orders/:id", func(c *gin.Context) { c.Status(http.StatusOK) }, httptest.NewRequest("GET", tt.url, nil))
+			if entry["route"] != tt.want {
+				t.Errorf("route = %v, want %s", entry["route"], tt.want)
+			}
+		})
+	}
+}
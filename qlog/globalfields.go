@@ -0,0 +1,66 @@
+package qlog
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewProductionWithFields builds a Logger like NewProduction adding fields,
+// e.g. the environment, region or cluster, to every entry. A field passed at
+// the log site, or derived from Context, with the same key replaces the
+// global one.
+func NewProductionWithFields(context interface{}, fields map[string]interface{}) *Logger {
+	global := make([]zap.Field, 0, len(fields))
+	for k, v := range fields {
+		global = append(global, anyField(k, v))
+	}
+	sort.Slice(global, func(i, j int) bool { return global[i].Key < global[j].Key })
+
+	l := NewProduction(context)
+	wrap := l.wrapCore(func(core zapcore.Core) zapcore.Core {
+		return &globalFieldsCore{Core: core, global: global}
+	})
+	l.Zap = l.Zap.WithOptions(wrap)
+	l.audit = l.audit.WithOptions(wrap)
+	return l
+}
+
+// globalFieldsCore is a zapcore.Core adding the global fields not overridden
+// by the fields of an entry.
+type globalFieldsCore struct {
+	zapcore.Core
+	global []zapcore.Field
+}
+
+func (c *globalFieldsCore) With(fields []zapcore.Field) zapcore.Core {
+	return &globalFieldsCore{Core: c.Core.With(fields), global: c.global}
+}
+
+func (c *globalFieldsCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if next := checked(c.Core, ent); next != nil {
+		return ce.AddCore(ent, &globalFieldsCore{Core: next, global: c.global})
+	}
+	return ce
+}
+
+func (c *globalFieldsCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.global)+len(fields))
+	for _, g := range c.global {
+		if !hasKey(fields, g.Key) {
+			all = append(all, g)
+		}
+	}
+	return c.Core.Write(ent, append(all, fields...))
+}
+
+// hasKey reports whether one of fields has key.
+func hasKey(fields []zapcore.Field, key string) bool {
+	for _, f := range fields {
+		if f.Key == key {
+			return true
+		}
+	}
+	return false
+}
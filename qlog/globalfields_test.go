@@ -0,0 +1,32 @@
+package qlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewProductionWithFields(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	t.Setenv("LOG_HOST_PID", "false")
+	out := captureStderr(t, func() {
+		l := NewProductionWithFields(nil, map[string]interface{}{"env": "prod", "region": "sa-east-1", "shard": 3})
+		l.Info("default")
+		l.Info("override", "region", "us-east-1")
+		l.Audit("user.deleted")
+		_ = l.Sync()
+	})
+
+	entries := decodeEntries(t, out)
+	if len(entries) != 3 {
+		t.Fatalf("logged %d entries, want 3: %s", len(entries), out)
+	}
+	if n := strings.Count(out, `"region"`); n != len(entries) {
+		t.Errorf("region logged %d times, want once per entry", n)
+	}
+	for i, wantRegion := range []string{"sa-east-1", "us-east-1", "sa-east-1"} {
+		entry := entries[i]
+		if entry["env"] != "prod" || entry["shard"] != 3.0 || entry["region"] != wantRegion {
+			t.Errorf("%s entry = %v, want the global fields with region %s", entry["message"], entry, wantRegion)
+		}
+	}
+}
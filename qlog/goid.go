@@ -0,0 +1,58 @@
+package qlog
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// EnableGoroutineID returns an option adding to every entry a "goid" field
+// with the id of the goroutine that logged it, to tell concurrent flows apart
+// while chasing races. The id is parsed from runtime.Stack, which is best
+// effort and costly, so it is meant for debugging only. Use it with
+// NewProductionWithOptions.
+func EnableGoroutineID() zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &goidCore{Core: core}
+	})
+}
+
+// goidCore is a zapcore.Core adding the "goid" field to the entries.
+type goidCore struct {
+	zapcore.Core
+}
+
+func (c *goidCore) With(fields []zapcore.Field) zapcore.Core {
+	return &goidCore{Core: c.Core.With(fields)}
+}
+
+func (c *goidCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if next := checked(c.Core, ent); next != nil {
+		return ce.AddCore(ent, &goidCore{Core: next})
+	}
+	return ce
+}
+
+func (c *goidCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// Write runs on the goroutine that logged the entry.
+	if id, ok := goroutineID(); ok {
+		fields = append(fields[:len(fields):len(fields)], zap.Uint64("goid", id))
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// goroutineID returns the id of the calling goroutine, parsed from the
+// "goroutine 18 [running]:" header of its stack.
+func goroutineID() (uint64, bool) {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	return id, err == nil
+}
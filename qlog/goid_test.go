@@ -0,0 +1,38 @@
+package qlog
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestEnableGoroutineID(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	own, ok := goroutineID()
+	if !ok || own == 0 {
+		t.Fatalf("goroutineID() = %d, %t", own, ok)
+	}
+	out := captureStderr(t, func() {
+		l := NewProductionWithOptions(nil, EnableGoroutineID())
+		l.Info("main")
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.With().Info("worker")
+		}()
+		wg.Wait()
+		_ = l.Sync()
+	})
+
+	entries := decodeEntries(t, out)
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2: %s", len(entries), out)
+	}
+	if got := entries[0]["goid"]; got != float64(own) {
+		t.Errorf("main goid = %v, want %d", got, own)
+	}
+	worker, ok := entries[1]["goid"].(float64)
+	if !ok || worker == 0 || worker == float64(own) {
+		t.Errorf("worker goid = %v, want the id of its own goroutine", entries[1]["goid"])
+	}
+}
@@ -0,0 +1,28 @@
+package qlog
+
+import (
+	"context"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataXRequestID is the incoming gRPC metadata key carrying the request id.
+const metadataXRequestID = "x-request-id"
+
+// UnaryServerInterceptor returns a gRPC interceptor that reads the request id
+// from the incoming metadata, stores it on the request context together with a
+// Logger bound to that context, and then calls the handler. Handlers retrieve
+// the Logger with FromContext.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	base := NewProduction(nil)
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if ids := md.Get(metadataXRequestID); len(ids) > 0 && !stg.IsEmpty(&ids[0]) {
+				ctx = ContextWithRequestID(ctx, ids[0])
+			}
+		}
+		return handler(NewContext(ctx, base.WithContext(ctx)), req)
+	}
+}
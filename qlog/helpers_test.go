@@ -0,0 +1,86 @@
+package qlog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	// The debug output of a developer's environment would replace the output
+	// the tests assert on; the GO_DEBUG tests set it themselves.
+	os.Unsetenv("GO_DEBUG")
+	os.Unsetenv("GO_DEBUG_PRETTY")
+}
+
+// fieldMap returns the values of fields by key, as a map encoder sees them.
+func fieldMap(fields []zap.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// newGinContext returns a *gin.Context serving req.
+func newGinContext(req *http.Request) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = req
+	return c
+}
+
+// captureStderr returns what the loggers built by fn write to standard error.
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stderr, fn)
+}
+
+// captureStdout returns what the loggers built by fn write to standard output.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	return capture(t, &os.Stdout, fn)
+}
+
+// capture returns what is written to *std while fn runs.
+func capture(t *testing.T, std **os.File, fn func()) string {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "std")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	saved := *std
+	*std = f
+	defer func() { *std = saved }()
+	fn()
+	out, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// decodeEntries decodes the JSON entries written one per line in out.
+func decodeEntries(t *testing.T, out string) []map[string]interface{} {
+	t.Helper()
+	var entries []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("decode %q: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
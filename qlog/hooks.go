@@ -0,0 +1,61 @@
+package qlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelCounts counts the entries written at each level, from DebugLevel to
+// FatalLevel.
+type levelCounts [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Int64
+
+// count is the zap hook incrementing the counter of the entry level.
+func (c *levelCounts) count(ent zapcore.Entry) error {
+	if ent.Level >= zapcore.DebugLevel && ent.Level <= zapcore.FatalLevel {
+		c[ent.Level-zapcore.DebugLevel].Add(1)
+	}
+	return nil
+}
+
+// WithMetrics returns a child logger counting the entries it writes at each
+// level. The counts are read with LevelCounts.
+func (l *Logger) WithMetrics() *Logger {
+	counts := &levelCounts{}
+	child := *l
+	child.Zap = l.Zap.WithOptions(zap.Hooks(counts.count))
+	if l.audit != nil {
+		child.audit = l.audit.WithOptions(zap.Hooks(counts.count))
+	}
+	child.counts = counts
+	return &child
+}
+
+// LevelCounts returns how many entries were written at each level by the
+// loggers returned by WithMetrics and their children. It is nil when metrics
+// are not enabled.
+func (l *Logger) LevelCounts() map[LevelError]int64 {
+	if l.counts == nil {
+		return nil
+	}
+	out := make(map[LevelError]int64, len(l.counts))
+	for i := range l.counts {
+		lvl := zapcore.DebugLevel + zapcore.Level(i)
+		out[LevelError(lvl.String())] = l.counts[i].Load()
+	}
+	return out
+}
+
+// AddHook returns a child logger calling fn for each entry it writes, after
+// the entry is written. Hooks added on top of each other all run. An error
+// returned by fn doesn't prevent the entry from being written; it is reported
+// to the logger's error output.
+func (l *Logger) AddHook(fn func(zapcore.Entry) error) *Logger {
+	child := *l
+	child.Zap = l.Zap.WithOptions(zap.Hooks(fn))
+	if l.audit != nil {
+		child.audit = l.audit.WithOptions(zap.Hooks(fn))
+	}
+	return &child
+}
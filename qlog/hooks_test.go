@@ -0,0 +1,63 @@
+package qlog
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithMetrics(t *testing.T) {
+	l, _ := NewObserved(nil)
+	if got := l.LevelCounts(); got != nil {
+		t.Errorf("LevelCounts() = %v without metrics, want nil", got)
+	}
+	m := l.WithMetrics()
+	if err := m.SetLevel(InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	m.Debug("disabled")
+	m.Info("a")
+	m.With().Info("b")
+	m.Warn("c")
+	m.Error("d")
+	m.Audit("e")
+
+	want := map[LevelError]int64{
+		DebugLevel: 0, InfoLevel: 3, WarnLevel: 1, ErrorLevel: 1,
+		DPanicLevel: 0, PanicLevel: 0, FatalLevel: 0,
+	}
+	if got := m.LevelCounts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("LevelCounts() = %v, want %v", got, want)
+	}
+}
+
+func TestAddHook(t *testing.T) {
+	var errOut bytes.Buffer
+	base, logs := NewObserved(nil)
+	base.Zap = base.Zap.WithOptions(zap.ErrorOutput(zapcore.AddSync(&errOut)))
+	var seen []string
+	l := base.AddHook(func(ent zapcore.Entry) error {
+		seen = append(seen, "first:"+ent.Message)
+		return nil
+	}).AddHook(func(ent zapcore.Entry) error {
+		seen = append(seen, "second:"+ent.Message)
+		return errors.New("hook failed")
+	})
+	l.Info("hello")
+	base.Info("parent")
+
+	if want := []string{"first:hello", "second:hello"}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("hooks saw %v, want %v", seen, want)
+	}
+	if n := logs.Len(); n != 2 {
+		t.Errorf("logged %d entries, want 2", n)
+	}
+	if !strings.Contains(errOut.String(), "hook failed") {
+		t.Errorf("error output = %q, want the hook error", errOut.String())
+	}
+}
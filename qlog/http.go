@@ -0,0 +1,141 @@
+package qlog
+
+import (
+	"encoding/base64"
+	"net"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/net/publicsuffix"
+)
+
+// RequestIDMiddleware is a net/http middleware giving every request a request
+// id: the X-Request-ID header when present or a new UUID otherwise. The id is
+// stored on the request's context.Context, where logFromContext reads it for
+// both *http.Request and context.Context loggers, and echoed back in the
+// X-Request-ID response header.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(headerXRequestID)
+		if stg.IsEmpty(&id) {
+			id = newRequestID()
+		}
+		w.Header().Set(headerXRequestID, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// httpRequestFields is the Extractor of *http.Request.
+func httpRequestFields(ctx interface{}) (fields []zap.Field) {
+	r := ctx.(*http.Request)
+	if uuid := RequestIDFromContext(r.Context()); !stg.IsEmpty(&uuid) {
+		fields = append(fields, zap.String(KeyXRequestID, uuid))
+	}
+	if session := SessionIDFromContext(r.Context()); !stg.IsEmpty(&session) {
+		fields = append(fields, zap.String(KeySessionID, session))
+	}
+	if prefix := domainPrefix(r.Host); !stg.IsEmpty(&prefix) {
+		fields = append(fields, zap.String(KeyDomainPrefix, prefix))
+	}
+	return fields
+}
+
+// RequestHeaderFields returns the access-log fields of the headers of r: its
+// Content-Length under "content_length" and its User-Agent under
+// "user_agent". Absent or empty headers are omitted.
+func RequestHeaderFields(r *http.Request) (fields []zap.Field) {
+	// ContentLength is 0 both for an empty body and an absent header.
+	if n := r.ContentLength; n > 0 || (n == 0 && r.Header.Get("Content-Length") != "") {
+		fields = append(fields, zap.Int64("content_length", n))
+	}
+	if ua := r.UserAgent(); !stg.IsEmpty(&ua) {
+		fields = append(fields, zap.String("user_agent", ua))
+	}
+	return fields
+}
+
+// domainPrefix returns the leftmost label of host, e.g. "tenant" for
+// "tenant.app.com:8080", used to tell tenants apart. It is empty when host has
+// no subdomain, such as the bare "app.com" or "app.com.br" serving no tenant
+// in particular, for a single label host and for an IP address. The domain is
+// found with the public suffix list.
+func domainPrefix(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if net.ParseIP(host) != nil {
+		return ""
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil || domain == host {
+		return ""
+	}
+	prefix, _, _ := strings.Cut(host, ".")
+	return prefix
+}
+
+// InjectRequestID wraps rt, http.DefaultTransport when nil, so outgoing
+// requests carry the request id of their context, as set by
+// ContextWithRequestID or RequestIDMiddleware, in the X-Request-ID header. A
+// header already set on the request is kept.
+func InjectRequestID(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		id := RequestIDFromContext(req.Context())
+		if stg.IsEmpty(&id) || req.Header.Get(headerXRequestID) != "" {
+			return rt.RoundTrip(req)
+		}
+		// A RoundTripper must not modify the request it was given.
+		req = req.Clone(req.Context())
+		req.Header.Set(headerXRequestID, id)
+		return rt.RoundTrip(req)
+	})
+}
+
+// roundTripperFunc adapts an ordinary function to an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// LogResponse logs a response with its status and body, at InfoLevel for
+// 1xx to 3xx statuses and at ErrorLevel from 400. When maxBytes is positive
+// the body is capped to maxBytes and "truncated":true is added. A body that
+// isn't valid UTF-8 is base64-encoded and flagged with
+// "body_encoding":"base64".
+func (l *Logger) LogResponse(status int, body []byte, maxBytes int) {
+	lvl := zapcore.InfoLevel
+	if status >= http.StatusBadRequest {
+		lvl = zapcore.ErrorLevel
+	}
+	l.logFields(lvl, "response", responseFields(status, body, maxBytes))
+}
+
+// responseFields returns the fields logged by LogResponse.
+func responseFields(status int, body []byte, maxBytes int) []zap.Field {
+	fields := []zap.Field{zap.Int("status", status)}
+	binary := !utf8.Valid(body)
+	if maxBytes > 0 && len(body) > maxBytes {
+		n := maxBytes
+		// Don't cut a text body in the middle of a character.
+		for !binary && n > 0 && !utf8.RuneStart(body[n]) {
+			n--
+		}
+		body = body[:n]
+		fields = append(fields, zap.Bool("truncated", true))
+	}
+	if binary {
+		return append(fields,
+			zap.String("body", base64.StdEncoding.EncodeToString(body)),
+			zap.String("body_encoding", "base64"),
+		)
+	}
+	return append(fields, zap.ByteString("body", body))
+}
@@ -0,0 +1,212 @@
+package qlog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"header", "req-1"},
+		{"generated", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(headerXRequestID, tt.header)
+			}
+			var fromRequest, fromContext interface{}
+			h := RequestIDMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				fromRequest = fieldMap(NewNop().WithContext(r).Fields())[KeyXRequestID]
+				fromContext = fieldMap(NewNop().WithContext(r.Context()).Fields())[KeyXRequestID]
+			}))
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(headerXRequestID)
+			if tt.header != "" && id != tt.header {
+				t.Errorf("request id = %q, want %q", id, tt.header)
+			}
+			if tt.header == "" && !uuidPattern.MatchString(id) {
+				t.Errorf("request id = %q, want a UUID", id)
+			}
+			if fromRequest != id || fromContext != id {
+				t.Errorf("logged %s = %v and %v, want %q", KeyXRequestID, fromRequest, fromContext, id)
+			}
+		})
+	}
+}
+
+func TestInjectRequestID(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Got", r.Header.Get(headerXRequestID))
+	}))
+	defer srv.Close()
+	client := &http.Client{Transport: InjectRequestID(nil)}
+
+	tests := []struct {
+		name   string
+		ctxID  string
+		header string
+		want   string
+	}{
+		{"from context", "req-1", "", "req-1"},
+		{"header kept", "req-1", "explicit", "explicit"},
+		{"none", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.ctxID != "" {
+				ctx = ContextWithRequestID(ctx, tt.ctxID)
+			}
+			req, err := http.NewRequestWithContext(ctx, "GET", srv.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.header != "" {
+				req.Header.Set(headerXRequestID, tt.header)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := resp.Header.Get("Got"); got != tt.want {
+				t.Errorf("server got %q, want %q", got, tt.want)
+			}
+			if got := req.Header.Get(headerXRequestID); got != tt.header {
+				t.Errorf("request header = %q, want it untouched", got)
+			}
+		})
+	}
+}
+
+func TestLogResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      []byte
+		maxBytes  int
+		wantLevel zapcore.Level
+		want      map[string]interface{}
+	}{
+		{
+			name: "ok", status: 200, body: []byte(`{"id":1}`), wantLevel: zapcore.InfoLevel,
+			want: map[string]interface{}{"status": int64(200), "body": `{"id":1}`},
+		},
+		{
+			name: "client error", status: 404, body: []byte("not found"), wantLevel: zapcore.ErrorLevel,
+			want: map[string]interface{}{"status": int64(404), "body": "not found"},
+		},
+		{
+			name: "truncated", status: 200, body: []byte("abcdef"), maxBytes: 4, wantLevel: zapcore.InfoLevel,
+			want: map[string]interface{}{"status": int64(200), "body": "abcd", "truncated": true},
+		},
+		{
+			name: "rune boundary", status: 200, body: []byte("aé"), maxBytes: 2, wantLevel: zapcore.InfoLevel,
+			want: map[string]interface{}{"status": int64(200), "body": "a", "truncated": true},
+		},
+		{
+			name: "binary", status: 500, body: []byte{0xff, 0xfe, 0x00}, wantLevel: zapcore.ErrorLevel,
+			want: map[string]interface{}{"status": int64(500), "body": "//4A", "body_encoding": "base64"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			l.LogResponse(tt.status, tt.body, tt.maxBytes)
+
+			entry := logs.All()[0]
+			if entry.Level != tt.wantLevel {
+				t.Errorf("level = %v, want %v", entry.Level, tt.wantLevel)
+			}
+			if got := entry.ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDomainPrefix(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"tenant.app.com", "tenant"},
+		{"tenant.app.com:8080", "tenant"},
+		{"tenant.app.com.br", "tenant"},
+		{"app.com", ""},
+		{"app.com.br:443", ""},
+		{"1.2.3.4:8080", ""},
+		{"localhost", ""},
+		{"localhost:8080", ""},
+		{"10.0.0.1", ""},
+		{"[::1]:8080", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := domainPrefix(tt.host); got != tt.want {
+			t.Errorf("domainPrefix(%q) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestDomainPrefixField(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://acme.app.com/", nil)
+	for name, ctx := range map[string]interface{}{"*http.Request": req, "*gin.Context": newGinContext(req)} {
+		if got := fieldMap(NewNop().WithContext(ctx).Fields())[KeyDomainPrefix]; got != "acme" {
+			t.Errorf("%s: %s = %v, want acme", name, KeyDomainPrefix, got)
+		}
+	}
+}
+
+func TestRequestHeaderFields(t *testing.T) {
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		want map[string]interface{}
+	}{
+		{
+			name: "body and user agent",
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/orders", strings.NewReader(`{"id":1}`))
+				req.Header.Set("User-Agent", "curl/8.5.0")
+				return req
+			},
+			want: map[string]interface{}{"content_length": int64(8), "user_agent": "curl/8.5.0"},
+		},
+		{
+			name: "empty body",
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/orders", nil)
+				req.Header.Set("Content-Length", "0")
+				return req
+			},
+			want: map[string]interface{}{"content_length": int64(0)},
+		},
+		{
+			name: "no headers",
+			req:  func() *http.Request { return httptest.NewRequest("GET", "/orders", nil) },
+			want: map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldMap(RequestHeaderFields(tt.req())); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,14 @@
 // Package qlog defines a custom error level type and a set of constants representing different levels of errors.
 package qlog
 
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
 // LevelError is a custom type used to represent different levels of errors.
 type LevelError string
 
@@ -15,3 +23,46 @@ const (
 	PanicLevel  LevelError = "panic"
 	FatalLevel  LevelError = "fatal"
 )
+
+// zapLevels maps each LevelError to the corresponding zapcore.Level.
+var zapLevels = map[LevelError]zapcore.Level{
+	DebugLevel:  zapcore.DebugLevel,
+	InfoLevel:   zapcore.InfoLevel,
+	WarnLevel:   zapcore.WarnLevel,
+	ErrorLevel:  zapcore.ErrorLevel,
+	DPanicLevel: zapcore.DPanicLevel,
+	PanicLevel:  zapcore.PanicLevel,
+	FatalLevel:  zapcore.FatalLevel,
+}
+
+// zapLevel returns the zapcore.Level for l and whether l is a known level.
+func (l LevelError) zapLevel() (zapcore.Level, bool) {
+	lvl, ok := zapLevels[l]
+	return lvl, ok
+}
+
+// ParseLevel parses a level name, case-insensitively, into a LevelError. It
+// returns InfoLevel and an error when s is not a known level.
+func ParseLevel(s string) (LevelError, error) {
+	level := LevelError(strings.ToLower(strings.TrimSpace(s)))
+	if _, ok := level.zapLevel(); !ok {
+		return InfoLevel, fmt.Errorf("qlog: unrecognized level %q", s)
+	}
+	return level, nil
+}
+
+// ZapLevel converts a LevelError into the corresponding zapcore.Level. Unknown
+// levels are converted to zapcore.InfoLevel.
+func ZapLevel(level LevelError) zapcore.Level {
+	if lvl, ok := level.zapLevel(); ok {
+		return lvl
+	}
+	return zapcore.InfoLevel
+}
+
+// envLevel returns the level configured in the LOG_LEVEL environment variable,
+// falling back to InfoLevel when it is unset or invalid.
+func envLevel() LevelError {
+	level, _ := ParseLevel(os.Getenv("LOG_LEVEL"))
+	return level
+}
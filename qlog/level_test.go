@@ -0,0 +1,150 @@
+package qlog
+
+import (
+	"bytes"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    LevelError
+		wantErr bool
+	}{
+		{"debug", DebugLevel, false},
+		{"INFO", InfoLevel, false},
+		{" Warn ", WarnLevel, false},
+		{"error", ErrorLevel, false},
+		{"dpanic", DPanicLevel, false},
+		{"panic", PanicLevel, false},
+		{"fatal", FatalLevel, false},
+		{"", InfoLevel, true},
+		{"verbose", InfoLevel, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) = %q, %v, want %q, error %t", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestZapLevel(t *testing.T) {
+	if got := ZapLevel(WarnLevel); got != zapcore.WarnLevel {
+		t.Errorf("ZapLevel(WarnLevel) = %v, want warn", got)
+	}
+	if got := ZapLevel("verbose"); got != zapcore.InfoLevel {
+		t.Errorf("ZapLevel(verbose) = %v, want info", got)
+	}
+}
+
+func TestEnvLevel(t *testing.T) {
+	tests := []struct {
+		env       string
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{"debug", true, true},
+		{"warn", false, false},
+		{"bogus", false, true},
+		{"", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.env)
+			l := NewProductionWithWriter(&bytes.Buffer{}, nil)
+			if got := l.DebugEnabled(); got != tt.wantDebug {
+				t.Errorf("DebugEnabled() = %t, want %t", got, tt.wantDebug)
+			}
+			if got := l.InfoEnabled(); got != tt.wantInfo {
+				t.Errorf("InfoEnabled() = %t, want %t", got, tt.wantInfo)
+			}
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	tests := []struct {
+		env                      string
+		debug, info, warn, error bool
+	}{
+		{"debug", true, true, true, true},
+		{"info", false, true, true, true},
+		{"warn", false, false, true, true},
+		{"error", false, false, false, true},
+		{"fatal", false, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", tt.env)
+			l := NewProductionWithWriter(&bytes.Buffer{}, nil)
+			got := []bool{l.DebugEnabled(), l.InfoEnabled(), l.WarnEnabled(), l.ErrorEnabled()}
+			want := []bool{tt.debug, tt.info, tt.warn, tt.error}
+			for i, name := range []string{"Debug", "Info", "Warn", "Error"} {
+				if got[i] != want[i] {
+					t.Errorf("%sEnabled() = %t, want %t", name, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSetStacktraceLevel(t *testing.T) {
+	parent, logs := NewObserved(nil)
+	child := parent.With()
+	child.Warn("before")
+	if err := parent.SetStacktraceLevel(WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	child.Warn("after")
+	child.Info("info")
+
+	entries := logs.All()
+	for i, want := range []bool{false, true, false} {
+		if got := entries[i].Stack != ""; got != want {
+			t.Errorf("%q has a stacktrace %t, want %t", entries[i].Message, got, want)
+		}
+	}
+	if err := parent.SetStacktraceLevel("verbose"); err == nil {
+		t.Error("SetStacktraceLevel(verbose) = nil, want an error")
+	}
+	if err := NewNop().SetStacktraceLevel(WarnLevel); err == nil {
+		t.Error("SetStacktraceLevel() on NewNop = nil, want an error")
+	}
+}
+
+func TestSetLevel(t *testing.T) {
+	tests := []struct {
+		level   LevelError
+		want    LevelError
+		wantErr bool
+	}{
+		{DebugLevel, DebugLevel, false},
+		{WarnLevel, WarnLevel, false},
+		{ErrorLevel, ErrorLevel, false},
+		{FatalLevel, FatalLevel, false},
+		{"verbose", InfoLevel, true},
+		{"", InfoLevel, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.level), func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", "")
+			l := NewProductionWithWriter(&bytes.Buffer{}, nil)
+			child := l.With()
+			if err := l.SetLevel(tt.level); (err != nil) != tt.wantErr {
+				t.Errorf("SetLevel(%q) = %v, want error %t", tt.level, err, tt.wantErr)
+			}
+			if got := l.GetLevel(); got != tt.want {
+				t.Errorf("GetLevel() = %q, want %q", got, tt.want)
+			}
+			if got := child.GetLevel(); got != tt.want {
+				t.Errorf("child GetLevel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if err := NewNop().SetLevel(InfoLevel); err == nil {
+		t.Error("SetLevel() on NewNop = nil, want an error")
+	}
+}
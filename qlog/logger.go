@@ -1,160 +1,903 @@
 package qlog
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"io"
+	"io/fs"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	stg "github.com/correctinho/correct-util-sdk-go/stg"
-	"github.com/gin-gonic/gin"
-	"github.com/valyala/fasthttp"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 // Keys used for logging context JSON.
 const (
-	KeyAPIRequestID = "api-request-id"
-	KeyDomainName   = "domain-name"
-	KeySourceIP     = "source-ip"
-	KeyProtocol     = "protocol"
-	KeyAPIKey       = "api-key"
-	KeyDomainPrefix = "domain-prefix"
-	KeyXRequestID   = "x-request-id"
-	KeyAccount      = "account"
-	KeyService      = "service"
-	KeyRequestURI   = "request_uri"
+	KeyAPIRequestID   = "api-request-id"
+	KeyDomainName     = "domain-name"
+	KeySourceIP       = "source-ip"
+	KeyProtocol       = "protocol"
+	KeyAPIKey         = "api-key"
+	KeyDomainPrefix   = "domain-prefix"
+	KeyXRequestID     = "x-request-id"
+	KeyAccount        = "account"
+	KeyService        = "service"
+	KeyServiceVersion = "service_version"
+	KeyRequestURI     = "request_uri"
+	KeyTraceID        = "trace_id"
+	KeySpanID         = "span_id"
+	KeySessionID      = "session_id"
 )
 
 // Logger - struct para controle de log
+//
+// Context must not be reassigned on a Logger shared across goroutines; use
+// WithContext to derive a Logger bound to another context instead.
 type Logger struct {
-	Context interface{}
-	Zap     *zap.Logger
+	Context          interface{}
+	Zap              *zap.Logger
+	audit            *zap.Logger
+	auditWriter      bool
+	level            zap.AtomicLevel
+	stacktrace       zap.AtomicLevel
+	syncer           *throttledSync
+	sensitive        *sensitiveKeys
+	closers          []func() error
+	counts           *levelCounts
+	newRelic         bool
+	clock            Clock
+	requestNamespace bool
 }
 
+// LoggerIface is the set of Logger methods most code depends on. Accepting a
+// LoggerIface instead of a *Logger lets tests inject a mock; With still returns
+// a *Logger, so a mock can return NewNop().
+type LoggerIface interface {
+	Fatal(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Debug(msg string, keysAndValues ...interface{})
+	InfoJSON(msg, jbs string, keys LoggerExtras)
+	With(fields ...zap.Field) *Logger
+	Sync() error
+}
+
+var _ LoggerIface = (*Logger)(nil)
+
 // NewProduction builds a sensible production Logger that writes InfoLevel and
-// above logs to standard error as JSON.
+// above logs to standard error as JSON. The minimum level can be changed with
+// the LOG_LEVEL environment variable. Every entry carries the "host" and "pid"
+// of the process, unless LOG_HOST_PID is set to false, e.g. in tests.
 func NewProduction(context interface{}) *Logger {
+	return withProcessFields(NewProductionWithOptions(context))
+}
+
+// NewProductionE is like NewProduction but returns the error building the
+// Logger instead of falling back to a Logger writing to standard error.
+func NewProductionE(context interface{}) (*Logger, error) {
+	l, err := buildLoggerE(context, productionConfig())
+	if err != nil {
+		return nil, err
+	}
+	return withProcessFields(l), nil
+}
+
+// withProcessFields binds the "host" and "pid" fields to l, unless
+// LOG_HOST_PID is false.
+func withProcessFields(l *Logger) *Logger {
+	if on, err := strconv.ParseBool(os.Getenv("LOG_HOST_PID")); err == nil && !on {
+		return l
+	}
+	return l.With(processFields()...)
+}
+
+// processFields returns the "host" and "pid" fields of the process.
+var processFields = sync.OnceValue(func() []zap.Field {
+	host, _ := os.Hostname()
+	return []zap.Field{zap.String("host", host), zap.Int("pid", os.Getpid())}
+})
+
+// NewLogger builds a Logger like NewProduction whose format follows the
+// environment: entries are written in a console format when LOG_FORMAT is
+// "console" or GO_DEBUG is set, and as JSON otherwise. The minimum level comes
+// from LOG_LEVEL, so the same binary can run locally and in production. Like
+// NewProduction, every entry carries the "host" and "pid" of the process,
+// unless LOG_HOST_PID is false.
+func NewLogger(context interface{}) *Logger {
+	cf := productionConfig()
+	_, debug := os.LookupEnv("GO_DEBUG")
+	if debug || strings.EqualFold(os.Getenv("LOG_FORMAT"), "console") {
+		cf.Encoding = "console"
+		cf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	}
+	return withProcessFields(buildLogger(context, cf))
+}
+
+// NewProductionWithOptions builds a Logger like NewProduction and applies the
+// given zap options, e.g. zap.AddCaller() or zap.AddStacktrace(zapcore.WarnLevel).
+// The caller annotation always points at the call site of the Logger method,
+// not at qlog itself.
+func NewProductionWithOptions(context interface{}, opts ...zap.Option) *Logger {
+	return buildLogger(context, productionConfig(), opts...)
+}
+
+// EncoderKeys overrides the keys of the entry fields written by the encoder.
+// An empty key keeps the production default: "level", "ts", "message" and
+// "caller".
+type EncoderKeys struct {
+	LevelKey   string
+	TimeKey    string
+	MessageKey string
+	CallerKey  string
+}
+
+// NewProductionWithKeys builds a Logger like NewProduction writing the level,
+// time, message and caller under the keys set in keys.
+func NewProductionWithKeys(context interface{}, keys EncoderKeys) *Logger {
+	cf := productionConfig()
+	for _, k := range []struct {
+		dst *string
+		key string
+	}{
+		{&cf.EncoderConfig.LevelKey, keys.LevelKey},
+		{&cf.EncoderConfig.TimeKey, keys.TimeKey},
+		{&cf.EncoderConfig.MessageKey, keys.MessageKey},
+		{&cf.EncoderConfig.CallerKey, keys.CallerKey},
+	} {
+		if !stg.IsEmpty(&k.key) {
+			*k.dst = k.key
+		}
+	}
+	return buildLogger(context, cf)
+}
+
+// NewProductionISO8601 builds a Logger like NewProduction that encodes the
+// "ts" field as an ISO8601 string instead of floating-point epoch seconds.
+func NewProductionISO8601(context interface{}) *Logger {
+	cf := productionConfig()
+	cf.EncoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
+	return buildLogger(context, cf)
+}
+
+// NewProductionWithFatalHook builds a Logger like NewProduction whose Fatal
+// method calls hook after writing the entry instead of os.Exit(1). If hook
+// returns, Fatal returns too; use panic in hook to stop the caller, e.g. to
+// assert on Fatal in tests.
+func NewProductionWithFatalHook(context interface{}, hook func()) *Logger {
+	return NewProductionWithOptions(context, zap.WithFatalHook(fatalHook(hook)))
+}
+
+// fatalHook adapts a func to a zapcore.CheckWriteHook.
+type fatalHook func()
+
+func (h fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h()
+}
+
+// NewProductionSampled builds a Logger like NewProduction with a custom
+// sampler: every second, for each message and level, the first initial entries
+// are logged and then only one out of every thereafter entries.
+func NewProductionSampled(context interface{}, initial, thereafter int) *Logger {
+	cf := productionConfig()
+	cf.Sampling = &zap.SamplingConfig{
+		Initial:    initial,
+		Thereafter: thereafter,
+	}
+	return buildLogger(context, cf)
+}
+
+// productionConfig returns the zap configuration behind NewProduction.
+func productionConfig() zap.Config {
 	cf := zap.NewProductionConfig()
-	cf.EncoderConfig.MessageKey = "message"
-	log, _ := cf.Build()
+	cf.Level = zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	cf.EncoderConfig = productionEncoderConfig()
+	return cf
+}
+
+// buildLogger builds cf into a Logger whose caller annotation points at the
+// call site of the Logger methods. When cf can't be built, e.g. because an
+// output can't be opened, it warns once on standard error and falls back to a
+// JSON Logger writing to standard error, so the service keeps running.
+func buildLogger(context interface{}, cf zap.Config, opts ...zap.Option) *Logger {
+	l, err := buildLoggerE(context, cf, opts...)
+	if err != nil {
+		buildFailed.Do(func() {
+			fmt.Fprintf(os.Stderr, "%v; logging to stderr instead\n", err)
+		})
+		return newLogger(context, debugCore(productionCore(zapcore.Lock(os.Stderr), cf.Level)), cf.Level, opts...)
+	}
+	return l
+}
+
+// buildFailed guards the warning written when a configuration can't be built.
+var buildFailed sync.Once
+
+// samplingOption returns the option sampling entries as set by s, like
+// zap.Config does.
+func samplingOption(s *zap.SamplingConfig) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		var opts []zapcore.SamplerOption
+		if s.Hook != nil {
+			opts = append(opts, zapcore.SamplerHook(s.Hook))
+		}
+		return zapcore.NewSamplerWithOptions(core, time.Second, s.Initial, s.Thereafter, opts...)
+	})
+}
+
+// buildLoggerE is like buildLogger but returns the error building cf.
+func buildLoggerE(context interface{}, cf zap.Config, opts ...zap.Option) (*Logger, error) {
+	sensitive := &sensitiveKeys{}
+	stacktrace := zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+	if cf.Development {
+		stacktrace.SetLevel(zapcore.WarnLevel)
+	}
+	if cf.DisableStacktrace {
+		stacktrace.SetLevel(zapcore.InvalidLevel)
+	}
+	opts = append([]zap.Option{zap.AddCallerSkip(2), zap.AddStacktrace(stacktrace)}, opts...)
+	opts = append(opts, zap.WrapCore(sensitive.maskCore))
+	// Sample like zap does, but only the operational entries: the audit
+	// entries are written to the same core, unsampled.
+	sampling := cf.Sampling
+	cf.Sampling = nil
+	base, err := cf.Build(zap.WrapCore(debugCore))
+	if err != nil {
+		return nil, fmt.Errorf("qlog: build logger: %w", err)
+	}
+	log := base.WithOptions(opts...)
+	audit := log
+	if sampling != nil {
+		log = base.WithOptions(append([]zap.Option{samplingOption(sampling)}, opts...)...)
+	}
 	return &Logger{
-		Zap:     log,
-		Context: context,
+		Zap:        log,
+		audit:      audit,
+		Context:    context,
+		level:      cf.Level,
+		stacktrace: stacktrace,
+		sensitive:  sensitive,
+	}, nil
+}
+
+// NewProductionWithWriter builds a Logger like NewProduction that writes its
+// JSON entries to w instead of standard error. Writes and syncs of w are
+// serialized, so w needn't be safe for concurrent use.
+func NewProductionWithWriter(w io.Writer, context interface{}) *Logger {
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	return newLogger(context, productionCore(zapcore.Lock(zapcore.AddSync(w)), level), level)
+}
+
+// NewProductionBuffered builds a Logger like NewProduction that buffers its
+// writes to standard error, flushing them every flushInterval, when the buffer
+// is full and on Sync. Close stops the background flushing.
+func NewProductionBuffered(context interface{}, flushInterval time.Duration) *Logger {
+	ws := &zapcore.BufferedWriteSyncer{
+		WS:            zapcore.Lock(os.Stderr),
+		FlushInterval: flushInterval,
 	}
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	l := newLogger(context, productionCore(ws, level), level)
+	l.closers = append(l.closers, ws.Stop)
+	return l
 }
 
-// Fatal logs a message at FatalLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (l *Logger) Fatal(msg string, keysAndValues ...interface{}) {
-	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(fmt.Sprintf(msg, keysAndValues...))
-		return
+// NewProductionFile builds a Logger like NewProduction that writes its JSON
+// entries to the file at path. The file is rotated once it reaches
+// maxMegabytes, keeping at most maxBackups old files for maxAgeDays days; a
+// zero value leaves the corresponding limit to lumberjack's default. Close
+// closes the file.
+func NewProductionFile(context interface{}, path string, maxMegabytes, maxBackups, maxAgeDays int) *Logger {
+	w := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxMegabytes,
+		MaxBackups: maxBackups,
+		MaxAge:     maxAgeDays,
 	}
-	nrfs := l.logFromContext(l.Context)
-	if len(keysAndValues) > 0 {
-		msg = fmt.Sprintf(msg, keysAndValues...)
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	l := newLogger(context, productionCore(zapcore.AddSync(w), level), level)
+	l.closers = append(l.closers, w.Close)
+	return l
+}
+
+// NewProductionTee builds a Logger like NewProduction that writes each JSON
+// entry both to standard error and to the file at path, which is created if
+// needed and appended to. Sync flushes both outputs and Close closes the file.
+func NewProductionTee(context interface{}, path string) (*Logger, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("qlog: open log file: %w", err)
+	}
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	core := zapcore.NewTee(
+		productionCore(zapcore.Lock(os.Stderr), level),
+		productionCore(zapcore.Lock(f), level),
+	)
+	l := newLogger(context, core, level)
+	l.closers = append(l.closers, f.Close)
+	return l, nil
+}
+
+// NewProductionSplit builds a Logger like NewProduction that writes the
+// entries below ErrorLevel to standard output and the ErrorLevel and above
+// entries to standard error. Sync flushes both outputs.
+func NewProductionSplit(context interface{}) *Logger {
+	return newSplitLogger(context, zapcore.Lock(os.Stdout), zapcore.Lock(os.Stderr))
+}
+
+// newSplitLogger builds the Logger of NewProductionSplit writing to out and
+// errOut.
+func newSplitLogger(context interface{}, out, errOut zapcore.WriteSyncer) *Logger {
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	low := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return level.Enabled(lvl) && lvl < zapcore.ErrorLevel
+	})
+	high := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return level.Enabled(lvl) && lvl >= zapcore.ErrorLevel
+	})
+	core := zapcore.NewTee(
+		zapcore.NewCore(zapcore.NewJSONEncoder(productionEncoderConfig()), out, low),
+		zapcore.NewCore(zapcore.NewJSONEncoder(productionEncoderConfig()), errOut, high),
+	)
+	return newLogger(context, core, level)
+}
+
+// NewObserved builds a Logger enabled from DebugLevel that keeps its entries
+// in memory instead of writing them out. The returned ObservedLogs lets tests
+// assert on the logged entries, including the fields derived from context and
+// the key/value pairs.
+func NewObserved(context interface{}) (*Logger, *observer.ObservedLogs) {
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	core, logs := observer.New(level)
+	return newLogger(context, core, level), logs
+}
+
+// productionEncoderConfig returns the encoder configuration used by the
+// production loggers.
+func productionEncoderConfig() zapcore.EncoderConfig {
+	ec := zap.NewProductionEncoderConfig()
+	ec.MessageKey = "message"
+	return ec
+}
+
+// productionCore returns a JSON core, as used by NewProduction, writing to ws.
+func productionCore(ws zapcore.WriteSyncer, level zap.AtomicLevel) zapcore.Core {
+	return zapcore.NewCore(zapcore.NewJSONEncoder(productionEncoderConfig()), ws, level)
+}
+
+// newLogger wraps core in a Logger with caller annotation pointing at the log
+// site and stacktraces from ErrorLevel, as NewProduction does.
+func newLogger(context interface{}, core zapcore.Core, level zap.AtomicLevel, opts ...zap.Option) *Logger {
+	stacktrace := zap.NewAtomicLevelAt(zapcore.ErrorLevel)
+	opts = append([]zap.Option{
+		zap.AddCaller(),
+		zap.AddCallerSkip(2),
+		zap.AddStacktrace(stacktrace),
+		zap.ErrorOutput(zapcore.Lock(os.Stderr)),
+	}, opts...)
+	sensitive := &sensitiveKeys{}
+	log := zap.New(core, append(opts, zap.WrapCore(sensitive.maskCore))...)
+	return &Logger{
+		Zap:        log,
+		audit:      log,
+		Context:    context,
+		level:      level,
+		stacktrace: stacktrace,
+		sensitive:  sensitive,
+	}
+}
+
+// FromCtx returns a production Logger bound to ctx, so every entry carries the
+// request id and OpenTelemetry trace_id and span_id found on ctx. The Loggers
+// returned share a Logger built on the first call, so FromCtx is cheap on
+// request paths and a level set with SetLevel on one of them applies to all.
+func FromCtx(ctx context.Context) *Logger {
+	return ctxLogger().WithContext(ctx)
+}
+
+// ctxLogger is the Logger the Loggers returned by FromCtx are derived from.
+var ctxLogger = sync.OnceValue(func() *Logger {
+	return NewProduction(nil)
+})
+
+// NewDevelopment builds a development Logger that writes DebugLevel and above
+// logs to standard error in a human-readable console format, with colored
+// levels and the caller of each log site.
+func NewDevelopment(context interface{}) *Logger {
+	cf := zap.NewDevelopmentConfig()
+	cf.Encoding = "console"
+	cf.EncoderConfig.MessageKey = "message"
+	cf.EncoderConfig.EncodeLevel = zapcore.LowercaseColorLevelEncoder
+	return buildLogger(context, cf)
+}
+
+// NewNop returns a Logger that never writes out logs, not even when GO_DEBUG is
+// set. It is meant for tests and short-lived tools that need a silent Logger.
+func NewNop() *Logger {
+	return &Logger{Zap: zap.NewNop()}
+}
+
+// WithContext returns a copy of the logger bound to ctx, from which the request
+// fields are derived. The copy shares the underlying zap logger, so deriving a
+// Logger per request is cheap and safe for concurrent use.
+func (l *Logger) WithContext(ctx interface{}) *Logger {
+	child := *l
+	child.Context = ctx
+	return &child
+}
+
+// With creates a child logger with the given fields bound to every entry it
+// logs, in addition to the fields derived from Context. The parent logger is
+// not affected.
+func (l *Logger) With(fields ...zap.Field) *Logger {
+	child := *l
+	child.Zap = l.Zap.With(fields...)
+	if l.audit != nil {
+		child.audit = l.audit.With(fields...)
+	}
+	return &child
+}
+
+// WithField creates a child logger with a single field bound to every entry
+// it logs, without requiring callers to build zap fields. Calls can be
+// chained, e.g. l.WithField("tenant", t).WithField("user_id", id).
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.With(anyField(key, value))
+}
+
+// WithRequestID creates a child logger binding id under KeyXRequestID, for
+// code that has a request id but no framework context to derive it from. It
+// composes with the other With methods and works with any Context, including
+// nil; it is not meant for a Context that already provides a request id.
+func (l *Logger) WithRequestID(id string) *Logger {
+	return l.With(zap.String(KeyXRequestID, id))
+}
+
+// WithNamespace creates a child logger nesting the fields bound afterwards
+// with With, and the fields of every entry, including those derived from
+// Context, under a name object, e.g. {"name":{"user_id":1}}. The parent
+// logger is not affected.
+func (l *Logger) WithNamespace(name string) *Logger {
+	return l.With(zap.Namespace(name))
+}
+
+// Named creates a child logger with name appended to the logger name, joined
+// by a dot, e.g. l.Named("payments").Named("worker") logs as
+// "payments.worker". Its audit entries carry the name too. The parent logger
+// is not affected.
+func (l *Logger) Named(name string) *Logger {
+	child := *l
+	child.Zap = l.Zap.Named(name)
+	if l.audit != nil {
+		child.audit = l.audit.Named(name)
 	}
-	l.Zap.Fatal(msg, nrfs...)
+	return &child
+}
+
+// Fields returns the fields derived from Context, including the service
+// fields, as they would be attached to an entry. Fields bound with With are
+// not included. It logs nothing and is meant to inspect middleware wiring.
+func (l *Logger) Fields() []zap.Field {
+	return l.logFromContext(l.Context)
+}
+
+// Fatal logs a message at FatalLevel and then calls os.Exit(1). The entry
+// includes the key/value pairs passed at the log site, as well as any fields
+// accumulated on the logger.
+//
+// keysAndValues are alternating keys and values, as in
+// l.Info("user created", "user_id", id); they are attached as structured
+// fields and are no longer formatted into msg.
+func (l *Logger) Fatal(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.FatalLevel, msg, keysAndValues)
+}
+
+// Panic logs a message at PanicLevel and then panics, even when the logger's
+// level is above PanicLevel. The entry includes the key/value pairs passed at
+// the log site, as well as any fields accumulated on the logger.
+func (l *Logger) Panic(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.PanicLevel, msg, keysAndValues)
 }
 
-// Error logs a message at ErrorLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
+// DPanic logs a message at DPanicLevel. In development loggers it then
+// panics. The entry includes the key/value pairs passed at the log site, as
+// well as any fields accumulated on the logger.
+func (l *Logger) DPanic(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.DPanicLevel, msg, keysAndValues)
+}
+
+// Error logs a message at ErrorLevel. The entry includes the key/value pairs
+// passed at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Error(msg string, keysAndValues ...interface{}) {
-	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(fmt.Sprintf(msg, keysAndValues...))
+	l.log(zapcore.ErrorLevel, msg, keysAndValues)
+}
+
+// Warn logs a message at WarnLevel. The entry includes the key/value pairs
+// passed at the log site, as well as any fields accumulated on the logger.
+func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.WarnLevel, msg, keysAndValues)
+}
+
+// Info logs a message at InfoLevel. The entry includes the key/value pairs
+// passed at the log site, as well as any fields accumulated on the logger.
+func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.InfoLevel, msg, keysAndValues)
+}
+
+// Debug logs a message at DebugLevel. The entry includes the key/value pairs
+// passed at the log site, as well as any fields accumulated on the logger.
+func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+	l.log(zapcore.DebugLevel, msg, keysAndValues)
+}
+
+// ErrorIf logs a message at ErrorLevel, like Error, only when cond is true.
+func (l *Logger) ErrorIf(cond bool, msg string, keysAndValues ...interface{}) {
+	if cond {
+		l.log(zapcore.ErrorLevel, msg, keysAndValues)
+	}
+}
+
+// WarnIf logs a message at WarnLevel, like Warn, only when cond is true.
+func (l *Logger) WarnIf(cond bool, msg string, keysAndValues ...interface{}) {
+	if cond {
+		l.log(zapcore.WarnLevel, msg, keysAndValues)
+	}
+}
+
+// InfoIf logs a message at InfoLevel, like Info, only when cond is true. When
+// cond is false no field is derived nor allocated.
+func (l *Logger) InfoIf(cond bool, msg string, keysAndValues ...interface{}) {
+	if cond {
+		l.log(zapcore.InfoLevel, msg, keysAndValues)
+	}
+}
+
+// DebugIf logs a message at DebugLevel, like Debug, only when cond is true.
+func (l *Logger) DebugIf(cond bool, msg string, keysAndValues ...interface{}) {
+	if cond {
+		l.log(zapcore.DebugLevel, msg, keysAndValues)
+	}
+}
+
+// warnedOnce holds the keys already logged by WarnOnce.
+var warnedOnce sync.Map
+
+// WarnOnce logs a message at WarnLevel, like Warn, only the first time key is
+// seen by the process, whatever the logger. It suits one-time deprecation or
+// migration notices on hot paths. A key is only marked as seen once a logger
+// enabled at WarnLevel logs it.
+func (l *Logger) WarnOnce(key, msg string, keysAndValues ...interface{}) {
+	if !l.output().Core().Enabled(zapcore.WarnLevel) {
 		return
 	}
-	nrfs := l.logFromContext(l.Context)
-	if len(keysAndValues) > 0 {
-		msg = fmt.Sprintf(msg, keysAndValues...)
+	if _, seen := warnedOnce.LoadOrStore(key, struct{}{}); !seen {
+		l.log(zapcore.WarnLevel, msg, keysAndValues)
 	}
-	l.Zap.Error(msg, nrfs...)
 }
 
-// Warn logs a message at WarnLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (l *Logger) Warn(msg string, keysAndValues ...interface{}) {
-	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(fmt.Sprintf(msg, keysAndValues...))
+// FatalFields logs a message at FatalLevel with the given fields, appended to
+// the fields derived from Context, and then calls os.Exit(1).
+func (l *Logger) FatalFields(msg string, fields ...zap.Field) {
+	l.logFields(zapcore.FatalLevel, msg, fields)
+}
+
+// ErrorFields logs a message at ErrorLevel with the given fields, appended to
+// the fields derived from Context.
+func (l *Logger) ErrorFields(msg string, fields ...zap.Field) {
+	l.logFields(zapcore.ErrorLevel, msg, fields)
+}
+
+// WarnFields logs a message at WarnLevel with the given fields, appended to
+// the fields derived from Context.
+func (l *Logger) WarnFields(msg string, fields ...zap.Field) {
+	l.logFields(zapcore.WarnLevel, msg, fields)
+}
+
+// InfoFields logs a message at InfoLevel with the given fields, appended to
+// the fields derived from Context. It avoids the key/value conversion of Info
+// on performance-critical paths.
+func (l *Logger) InfoFields(msg string, fields ...zap.Field) {
+	l.logFields(zapcore.InfoLevel, msg, fields)
+}
+
+// DebugFields logs a message at DebugLevel with the given fields, appended to
+// the fields derived from Context.
+func (l *Logger) DebugFields(msg string, fields ...zap.Field) {
+	l.logFields(zapcore.DebugLevel, msg, fields)
+}
+
+// logFields is like log with fields already built by the caller.
+func (l *Logger) logFields(lvl zapcore.Level, msg string, fields []zap.Field) {
+	if ce := l.output().Check(lvl, msg); ce != nil {
+		ce.Write(append(l.logFromContext(l.Context), fields...)...)
+	}
+}
+
+// log writes msg at lvl with the fields derived from Context followed by the
+// key/value pairs. It must be called directly by the exported logging methods
+// so the caller skip points at the user's log site.
+func (l *Logger) log(lvl zapcore.Level, msg string, keysAndValues []interface{}) {
+	if ce := l.output().Check(lvl, msg); ce != nil {
+		nrfs := l.logFromContext(l.Context)
+		ce.Write(append(nrfs, kvFields(keysAndValues)...)...)
+	}
+}
+
+// Timer starts a timer and returns a func logging, at InfoLevel, its message
+// with the elapsed time in milliseconds under "duration_ms", e.g.
+//
+//	defer l.Timer()("handler done")
+func (l *Logger) Timer() func(msg string, keysAndValues ...interface{}) {
+	start := l.now()
+	return func(msg string, keysAndValues ...interface{}) {
+		elapsed := Duration("duration_ms", l.now().Sub(start))
+		l.log(zapcore.InfoLevel, msg, append([]interface{}{elapsed}, keysAndValues...))
+	}
+}
+
+// InfoEach logs one entry at InfoLevel per item, each carrying the item under
+// the "item" key along with the fields derived from Context. Nothing is done
+// when InfoLevel is disabled.
+func (l *Logger) InfoEach(msg string, items []map[string]interface{}) {
+	l.logEach(zapcore.InfoLevel, msg, items)
+}
+
+// logEach is like log for the entries of InfoEach, one per item.
+func (l *Logger) logEach(lvl zapcore.Level, msg string, items []map[string]interface{}) {
+	out := l.output()
+	if !out.Core().Enabled(lvl) {
 		return
 	}
 	nrfs := l.logFromContext(l.Context)
-	if len(keysAndValues) > 0 {
-		msg = fmt.Sprintf(msg, keysAndValues...)
+	for _, item := range items {
+		if ce := out.Check(lvl, msg); ce != nil {
+			ce.Write(append(slices.Clip(nrfs), zap.Any("item", item))...)
+		}
 	}
-	l.Zap.Warn(msg, nrfs...)
 }
 
-// Info logs a message at InfoLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (l *Logger) Info(msg string, keysAndValues ...interface{}) {
-	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(fmt.Sprintf(msg, keysAndValues...))
+// InfoCtx logs a message at InfoLevel like Info, deriving the request fields
+// from ctx for this single entry. They override the same fields derived from
+// the logger's Context, which still provides the others.
+func (l *Logger) InfoCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logCtx(ctx, zapcore.InfoLevel, msg, keysAndValues)
+}
+
+// ErrorCtx logs a message at ErrorLevel like Error, deriving the request
+// fields from ctx for this single entry, as InfoCtx does.
+func (l *Logger) ErrorCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logCtx(ctx, zapcore.ErrorLevel, msg, keysAndValues)
+}
+
+// WarnCtx logs a message at WarnLevel like Warn, deriving the request fields
+// from ctx for this single entry, as InfoCtx does.
+func (l *Logger) WarnCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logCtx(ctx, zapcore.WarnLevel, msg, keysAndValues)
+}
+
+// DebugCtx logs a message at DebugLevel like Debug, deriving the request
+// fields from ctx for this single entry, as InfoCtx does.
+func (l *Logger) DebugCtx(ctx context.Context, msg string, keysAndValues ...interface{}) {
+	l.logCtx(ctx, zapcore.DebugLevel, msg, keysAndValues)
+}
+
+// CheckDeadline logs a warning, deriving the request fields from ctx as
+// WarnCtx does, when the deadline of ctx is less than threshold away, with the
+// time left in milliseconds under "remaining_ms" (negative once it has
+// passed). It does nothing when ctx has no deadline. Calling it at the steps
+// of a handler helps catching the slow ones before they time out.
+func (l *Logger) CheckDeadline(ctx context.Context, threshold time.Duration) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
 		return
 	}
-	nrfs := l.logFromContext(l.Context)
-	if len(keysAndValues) > 0 {
-		msg = fmt.Sprintf(msg, keysAndValues...)
+	remaining := deadline.Sub(l.now())
+	if remaining >= threshold {
+		return
 	}
-	l.Zap.Info(msg, nrfs...)
+	l.logCtx(ctx, zapcore.WarnLevel, "context deadline near", []interface{}{
+		Duration("remaining_ms", remaining),
+		zap.Time("deadline", deadline),
+	})
 }
 
-// Debug logs a message at DebugLevel. The message includes any fields passed
-// at the log site, as well as any fields accumulated on the logger.
-func (l *Logger) Debug(msg string, keysAndValues ...interface{}) {
+// logCtx is like log but merges the fields derived from ctx over the ones
+// derived from Context.
+func (l *Logger) logCtx(ctx context.Context, lvl zapcore.Level, msg string, keysAndValues []interface{}) {
+	if ce := l.output().Check(lvl, msg); ce != nil {
+		nrfs := mergeFields(l.logFromContext(l.Context), l.logFromContext(ctx))
+		ce.Write(append(nrfs, kvFields(keysAndValues)...)...)
+	}
+}
+
+// mergeFields returns the fields of base whose key isn't in override followed
+// by override.
+func mergeFields(base, override []zap.Field) []zap.Field {
+	fields := make([]zap.Field, 0, len(base)+len(override))
+	for _, f := range base {
+		if !slices.ContainsFunc(override, func(o zap.Field) bool { return o.Key == f.Key }) {
+			fields = append(fields, f)
+		}
+	}
+	return append(fields, override...)
+}
+
+// output returns the zap logger entries are written to: l.Zap, enabled from
+// the level override of the request.
+func (l *Logger) output() *zap.Logger {
+	return withRequestLevel(l.Zap, l.Context)
+}
+
+// debugCore returns the core the entries are written to instead of core when
+// a Logger built from a zap.Config, such as NewProduction or NewLogger, is
+// built with GO_DEBUG_PRETTY or GO_DEBUG set: a core writing every level to
+// standard output, as indented JSON or in a readable console format. Only the
+// output is replaced, the fields bound with With, masking, hooks and the other
+// options of the Logger still apply. The Loggers built on a writer or a core
+// of the caller, and NewObserved, keep their output. Both are for local use
+// only.
+func debugCore(core zapcore.Core) zapcore.Core {
+	if _, ok := os.LookupEnv("GO_DEBUG_PRETTY"); ok {
+		return prettyCore(zapcore.Lock(os.Stdout), zapcore.DebugLevel)
+	}
 	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(fmt.Sprintf(msg, keysAndValues...))
-		return
+		ec := zap.NewDevelopmentEncoderConfig()
+		ec.MessageKey = "message"
+		return zapcore.NewCore(zapcore.NewConsoleEncoder(ec), zapcore.Lock(os.Stdout), zapcore.DebugLevel)
 	}
-	nrfs := l.logFromContext(l.Context)
-	if len(keysAndValues) > 0 {
-		msg = fmt.Sprintf(msg, keysAndValues...)
+	return core
+}
+
+// badKey is the key used for a trailing value passed without its key.
+const badKey = "!BADKEY"
+
+// kvFields converts alternating keys and values into zap fields. A zap.Field
+// is used as is, keys that aren't strings are formatted with fmt.Sprint, a
+// time.Duration is logged in milliseconds and a trailing value without its key
+// is logged under "!BADKEY".
+func kvFields(keysAndValues []interface{}) []zap.Field {
+	fields := make([]zap.Field, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i++ {
+		if f, ok := keysAndValues[i].(zap.Field); ok {
+			fields = append(fields, f)
+			continue
+		}
+		if i == len(keysAndValues)-1 {
+			fields = append(fields, zap.Any(badKey, keysAndValues[i]))
+			break
+		}
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields = append(fields, anyField(key, keysAndValues[i+1]))
+		i++
 	}
-	l.Zap.Debug(msg, nrfs...)
+	return fields
 }
 
 // DebugEnabled - Valida modo debug
 func (l *Logger) DebugEnabled() bool {
-	ce := l.Zap.Check(zap.DebugLevel, "debugging")
+	ce := l.output().Check(zap.DebugLevel, "debugging")
 	return ce != nil
 }
 
-// Sync calls the underlying Core's Sync method, flushing any buffered log
-// entries. Applications should take care to call Sync before exiting.
-func (l *Logger) Sync() error {
-	return l.Zap.Sync()
+// InfoEnabled - Valida modo info
+func (l *Logger) InfoEnabled() bool {
+	ce := l.output().Check(zap.InfoLevel, "info")
+	return ce != nil
 }
 
-func (l *Logger) logFromContext(ctx interface{}) (fields []zap.Field) {
-	if value, ok := ctx.(*gin.Context); ok {
-		if uuid := value.GetString("request_id"); !stg.IsEmpty(&uuid) {
-			fields = append(fields, zap.String(KeyXRequestID, uuid))
-		}
-		if service, ok := os.LookupEnv("SERVICE_NAME"); ok {
-			fields = append(fields, zap.String(KeyService, service))
-		}
+// WarnEnabled - Valida modo warn
+func (l *Logger) WarnEnabled() bool {
+	ce := l.output().Check(zap.WarnLevel, "warn")
+	return ce != nil
+}
 
+// ErrorEnabled - Valida modo error
+func (l *Logger) ErrorEnabled() bool {
+	ce := l.output().Check(zap.ErrorLevel, "error")
+	return ce != nil
+}
+
+// SetLevel changes, at runtime, the minimum level enabled on the logger and on
+// every logger sharing its core. It returns an error for an unknown level.
+func (l *Logger) SetLevel(level LevelError) error {
+	lvl, ok := level.zapLevel()
+	if !ok {
+		return fmt.Errorf("qlog: unrecognized level %q", level)
+	}
+	if l.level == (zap.AtomicLevel{}) {
+		return errors.New("qlog: logger level is not adjustable")
 	}
+	l.level.SetLevel(lvl)
+	return nil
+}
 
-	if _, ok := ctx.(*http.Request); ok {
-		if service, ok := os.LookupEnv("SERVICE_NAME"); ok {
-			fields = append(fields, zap.String(KeyService, service))
-		}
+// SetStacktraceLevel changes, at runtime, the minimum level of the entries a
+// stacktrace is attached to, ErrorLevel by default, on the logger and on every
+// logger sharing its core, e.g. WarnLevel while chasing a bug. It returns an
+// error for an unknown level. A stacktrace level set with zap.AddStacktrace on
+// construction takes precedence.
+func (l *Logger) SetStacktraceLevel(level LevelError) error {
+	lvl, ok := level.zapLevel()
+	if !ok {
+		return fmt.Errorf("qlog: unrecognized level %q", level)
+	}
+	if l.stacktrace == (zap.AtomicLevel{}) {
+		return errors.New("qlog: logger stacktrace level is not adjustable")
+	}
+	l.stacktrace.SetLevel(lvl)
+	return nil
+}
 
+// GetLevel returns the minimum level currently enabled on the logger.
+func (l *Logger) GetLevel() LevelError {
+	if l.level == (zap.AtomicLevel{}) {
+		return LevelError(l.Zap.Level().String())
 	}
+	return LevelError(l.level.Level().String())
+}
 
-	if value, ok := ctx.(*fasthttp.RequestCtx); ok {
-		if uuid, ok := value.UserValue("request_id").(string); ok {
-			fields = append(fields, zap.String(KeyXRequestID, uuid))
-		}
-		if service, ok := os.LookupEnv("SERVICE_NAME"); ok {
-			fields = append(fields, zap.String(KeyService, service))
+// Sync calls the underlying Core's Sync method, flushing any buffered log
+// entries. Applications should take care to call Sync before exiting.
+func (l *Logger) Sync() error {
+	if l.syncer != nil {
+		return l.syncer.Sync()
+	}
+	return l.flush()
+}
+
+// flush syncs the output of l and, when it has its own, the audit output.
+func (l *Logger) flush() error {
+	err := l.Zap.Sync()
+	if l.auditWriter {
+		err = multierr.Append(err, l.audit.Sync())
+	}
+	return err
+}
+
+// Close flushes any buffered log entries and releases the resources held by
+// the logger, such as the flushing goroutine of NewProductionBuffered. The
+// logger, and every logger derived from it, must not be used afterwards.
+//
+// Unlike Sync, Close ignores the EINVAL and ENOTTY errors returned when
+// syncing standard output or standard error, e.g.
+// "sync /dev/stderr: invalid argument", since those descriptors often don't
+// support fsync. Flush errors of any other writer are returned.
+func (l *Logger) Close() error {
+	var err error
+	for _, e := range multierr.Errors(l.flush()) {
+		if !isStdSyncError(e) {
+			err = errors.Join(err, e)
 		}
+	}
+	for _, closer := range l.closers {
+		err = errors.Join(err, closer())
+	}
+	return err
+}
 
-		return
+// isStdSyncError reports whether err is the error returned when syncing a
+// standard output or standard error that doesn't support fsync.
+func isStdSyncError(err error) bool {
+	var pe *fs.PathError
+	if !errors.As(err, &pe) || (pe.Path != os.Stdout.Name() && pe.Path != os.Stderr.Name()) {
+		return false
 	}
-	return fields
+	return errors.Is(pe.Err, syscall.EINVAL) || errors.Is(pe.Err, syscall.ENOTTY)
 }
 
 // LoggerExtras - extras keys
@@ -162,21 +905,144 @@ type LoggerExtras struct {
 	Key    string
 	Value  map[string]interface{}
 	Filter []string
+	// AllowList, when not empty, lists the only top-level keys of Value
+	// that are logged. Filter still applies to the kept keys.
+	AllowList []string
+}
+
+// allow returns a copy of value holding only the keys present in allowList,
+// or value itself when allowList is empty.
+func allow(value map[string]interface{}, allowList []string) map[string]interface{} {
+	if len(allowList) == 0 {
+		return value
+	}
+	out := make(map[string]interface{}, len(allowList))
+	for _, k := range allowList {
+		if v, ok := value[k]; ok {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// redactedValue replaces the value of every key listed in LoggerExtras.Filter.
+const redactedValue = "[REDACTED]"
+
+// redact returns a copy of value where the keys present in filter, at any
+// nesting level, have their value replaced by redactedValue.
+func redact(value map[string]interface{}, filter []string) map[string]interface{} {
+	if len(filter) == 0 {
+		return value
+	}
+	out := make(map[string]interface{}, len(value))
+	for k, v := range value {
+		if slices.Contains(filter, k) {
+			out[k] = redactedValue
+			continue
+		}
+		out[k] = redactNested(v, filter)
+	}
+	return out
+}
+
+// redactNested applies redact to maps found inside v, including maps held by
+// slices.
+func redactNested(v interface{}, filter []string) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		return redact(value, filter)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = redactNested(item, filter)
+		}
+		return out
+	}
+	return v
+}
+
+// sanitize returns a copy of value where the values that can't be encoded as
+// JSON, at any nesting level, are replaced by their type name, e.g.
+// "chan int", so one bad value can't break the whole entry.
+func sanitize(value map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(value))
+	for k, v := range value {
+		out[k] = sanitizeNested(v)
+	}
+	return out
+}
+
+// sanitizeNested applies sanitize to v, including maps and slices held by v.
+func sanitizeNested(v interface{}) interface{} {
+	switch value := v.(type) {
+	case nil, string, bool, int, int64, int32, uint, uint64, uint32:
+		return v
+	case map[string]interface{}:
+		return sanitize(value)
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = sanitizeNested(item)
+		}
+		return out
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprintf("%T", v)
+	}
+	return v
+}
+
+// payloadKey is the key the JSON blob of InfoJSON and its siblings is
+// attached under.
+const payloadKey = "payload"
+
+// parsePayload returns the field of the JSON blob jbs, its parsed value, or
+// jbs as a string when it is not valid JSON. Numbers keep their precision.
+func parsePayload(jbs string) (zap.Field, bool) {
+	dec := json.NewDecoder(strings.NewReader(jbs))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil || dec.More() {
+		return zap.String(payloadKey, jbs), false
+	}
+	return zap.Any(payloadKey, value), true
 }
 
 // InfoJSON - print map
 func (l *Logger) InfoJSON(msg, jbs string, keys LoggerExtras) {
-	nrfs := l.logFromContext(l.Context)
-	if _, ok := os.LookupEnv("GO_DEBUG"); ok {
-		println(msg)
+	l.logJSON(zapcore.InfoLevel, msg, jbs, keys)
+}
+
+// ErrorJSON - print map at ErrorLevel
+func (l *Logger) ErrorJSON(msg, jbs string, keys LoggerExtras) {
+	l.logJSON(zapcore.ErrorLevel, msg, jbs, keys)
+}
+
+// WarnJSON - print map at WarnLevel
+func (l *Logger) WarnJSON(msg, jbs string, keys LoggerExtras) {
+	l.logJSON(zapcore.WarnLevel, msg, jbs, keys)
+}
+
+// DebugJSON - print map at DebugLevel, a no-op when debug is disabled
+func (l *Logger) DebugJSON(msg, jbs string, keys LoggerExtras) {
+	l.logJSON(zapcore.DebugLevel, msg, jbs, keys)
+}
+
+// logJSON logs msg at lvl with the JSON blob jbs attached under "payload",
+// as an object or an array like jbs, and keys.Value under keys.Key. When jbs
+// is not valid JSON it is attached as a string, and keys.Value is not.
+func (l *Logger) logJSON(lvl zapcore.Level, msg, jbs string, keys LoggerExtras) {
+	ce := l.output().Check(lvl, msg)
+	if ce == nil {
 		return
 	}
-	if !json.Valid([]byte(jbs)) {
-		l.Zap.Info(msg, nrfs...)
-		return
+	nrfs := l.logFromContext(l.Context)
+	payload, valid := parsePayload(jbs)
+	if strings.TrimSpace(jbs) != "" {
+		nrfs = append(nrfs, payload)
 	}
-	if !stg.IsEmpty(&keys.Key) && len(keys.Value) > 0 {
-		nrfs = append(nrfs, zap.Any(keys.Key, keys.Value))
+	if valid && !stg.IsEmpty(&keys.Key) && len(keys.Value) > 0 {
+		nrfs = append(nrfs, zap.Any(keys.Key, sanitize(redact(allow(keys.Value, keys.AllowList), keys.Filter))))
 	}
-	l.Zap.Info(fmt.Sprintf("%s %s", msg, jbs), nrfs...)
+	ce.Write(nrfs...)
 }
@@ -0,0 +1,1230 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestNewDevelopment(t *testing.T) {
+	out := captureStderr(t, func() {
+		l := NewDevelopment(nil)
+		l.Debug("hello", "user_id", 1)
+		_ = l.Sync()
+	})
+
+	for _, want := range []string{"\x1b[35mdebug\x1b[0m", "logger_test.go:", "hello", `{"user_id": 1}`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q doesn't contain %q", out, want)
+		}
+	}
+	if strings.HasPrefix(out, "{") {
+		t.Errorf("output %q is JSON, want the console format", out)
+	}
+}
+
+func TestWith(t *testing.T) {
+	parent, logs := NewObserved(nil)
+	child := parent.With(zap.String("tenant", "acme"))
+	child.Info("child")
+	parent.Info("parent")
+
+	entries := logs.All()
+	if got := entries[0].ContextMap()["tenant"]; got != "acme" {
+		t.Errorf("child tenant = %v, want acme", got)
+	}
+	if _, ok := entries[1].ContextMap()["tenant"]; ok {
+		t.Error("parent entry carries the child field")
+	}
+}
+
+func TestKeysAndValues(t *testing.T) {
+	tests := []struct {
+		name string
+		kvs  []interface{}
+		want map[string]interface{}
+	}{
+		{"pairs", []interface{}{"user_id", 1, "name", "ana"}, map[string]interface{}{"user_id": int64(1), "name": "ana"}},
+		{"non-string key", []interface{}{42, "x"}, map[string]interface{}{"42": "x"}},
+		{"trailing value", []interface{}{"a", 1, "orphan"}, map[string]interface{}{"a": int64(1), badKey: "orphan"}},
+		{"zap field", []interface{}{zap.Bool("ok", true), "a", 1}, map[string]interface{}{"ok": true, "a": int64(1)}},
+		{"duration", []interface{}{"took", 1500 * time.Microsecond}, map[string]interface{}{"took": 1.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			l.Info("100%s done", tt.kvs...)
+
+			entry := logs.All()[0]
+			if entry.Message != "100%s done" {
+				t.Errorf("message = %q, want it unformatted", entry.Message)
+			}
+			if got := entry.ContextMap(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInfoJSONFilter(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.InfoJSON("user", `{}`, LoggerExtras{
+		Key: "user",
+		Value: map[string]interface{}{
+			"name":     "ana",
+			"password": "secret",
+			"cards":    []interface{}{map[string]interface{}{"number": "4111", "brand": "visa"}},
+		},
+		Filter: []string{"password", "number"},
+	})
+
+	want := map[string]interface{}{
+		"name":     "ana",
+		"password": redactedValue,
+		"cards":    []interface{}{map[string]interface{}{"number": redactedValue, "brand": "visa"}},
+	}
+	if got := logs.All()[0].ContextMap()["user"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("user = %v, want %v", got, want)
+	}
+}
+
+func TestJSONLevels(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*Logger, string, string, LoggerExtras)
+		want zapcore.Level
+	}{
+		{"ErrorJSON", (*Logger).ErrorJSON, zapcore.ErrorLevel},
+		{"WarnJSON", (*Logger).WarnJSON, zapcore.WarnLevel},
+		{"InfoJSON", (*Logger).InfoJSON, zapcore.InfoLevel},
+		{"DebugJSON", (*Logger).DebugJSON, zapcore.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			tt.log(l, "msg", `{"id":1}`, LoggerExtras{Key: "extra", Value: map[string]interface{}{"a": "b"}})
+
+			entry := logs.All()[0]
+			if entry.Level != tt.want {
+				t.Errorf("level = %v, want %v", entry.Level, tt.want)
+			}
+			fields := entry.ContextMap()
+			if got, want := fields[payloadKey], map[string]interface{}{"id": json.Number("1")}; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s = %v, want %v", payloadKey, got, want)
+			}
+			if got := fields["extra"]; !reflect.DeepEqual(got, map[string]interface{}{"a": "b"}) {
+				t.Errorf("extra = %v", got)
+			}
+		})
+	}
+}
+
+func TestDebugJSONDisabled(t *testing.T) {
+	l, logs := NewObserved(nil)
+	if err := l.SetLevel(InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.DebugJSON("msg", `{"id":1}`, LoggerExtras{})
+
+	if n := logs.Len(); n != 0 {
+		t.Errorf("logged %d entries, want none", n)
+	}
+}
+
+func TestNewNop(t *testing.T) {
+	t.Setenv("GO_DEBUG", "1")
+	l := NewNop().With(zap.String("a", "b")).WithContext(context.Background())
+	l.Error("error", "k", "v")
+	l.Info("info")
+	l.InfoJSON("json", `{"id":1}`, LoggerExtras{})
+
+	if l.DebugEnabled() || l.ErrorEnabled() {
+		t.Error("a nop logger is enabled")
+	}
+	if err := l.Sync(); err != nil {
+		t.Errorf("Sync() = %v", err)
+	}
+	if err := l.Close(); err != nil {
+		t.Errorf("Close() = %v", err)
+	}
+}
+
+func TestNewProductionWithWriter(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var buf bytes.Buffer
+	l := NewProductionWithWriter(&buf, nil)
+	l.Info("hello", "user_id", 1)
+	l.Debug("hidden")
+
+	entries := decodeEntries(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1: %s", len(entries), buf.String())
+	}
+	entry := entries[0]
+	if entry["level"] != "info" || entry["message"] != "hello" || entry["user_id"] != 1.0 {
+		t.Errorf("entry = %v", entry)
+	}
+	if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "qlog/logger_test.go:") {
+		t.Errorf("caller = %q, want the log site", caller)
+	}
+}
+
+func TestNewProductionWithOptions(t *testing.T) {
+	out := captureStderr(t, func() {
+		l := NewProductionWithOptions(nil, zap.AddStacktrace(zapcore.WarnLevel))
+		l.Info("info")
+		l.Warn("warn")
+	})
+
+	entries := decodeEntries(t, out)
+	if len(entries) != 2 {
+		t.Fatalf("logged %d entries, want 2: %s", len(entries), out)
+	}
+	for _, entry := range entries {
+		if caller, _ := entry["caller"].(string); !strings.HasPrefix(caller, "qlog/logger_test.go:") {
+			t.Errorf("caller = %q, want the log site", caller)
+		}
+	}
+	if _, ok := entries[0]["stacktrace"]; ok {
+		t.Error("info entry has a stacktrace")
+	}
+	if _, ok := entries[1]["stacktrace"]; !ok {
+		t.Error("warn entry has no stacktrace")
+	}
+}
+
+func TestNewProductionSampled(t *testing.T) {
+	out := captureStderr(t, func() {
+		l := NewProductionSampled(nil, 2, 3)
+		for i := 0; i < 10; i++ {
+			l.Info("flood")
+		}
+		l.Info("other")
+	})
+
+	// The 1st, 2nd, 5th and 8th floods and the other message.
+	if n := len(decodeEntries(t, out)); n != 5 {
+		t.Errorf("logged %d entries, want 5: %s", n, out)
+	}
+}
+
+func TestNamed(t *testing.T) {
+	l, logs := NewObserved(nil)
+	child := l.Named("payments").Named("worker")
+	child.Info("child")
+	l.Info("parent")
+
+	entries := logs.All()
+	if got := entries[0].LoggerName; got != "payments.worker" {
+		t.Errorf("child name = %q, want payments.worker", got)
+	}
+	if got := entries[1].LoggerName; got != "" {
+		t.Errorf("parent name = %q, want none", got)
+	}
+}
+
+func TestGoDebug(t *testing.T) {
+	t.Setenv("GO_DEBUG", "1")
+	t.Setenv("LOG_HOST_PID", "false")
+	var out string
+	stderr := captureStderr(t, func() {
+		out = captureStdout(t, func() {
+			l := NewProduction(nil).WithRequestID("req-1").With(zap.String("tenant", "acme"))
+			l.Debug("hello", "user_id", 1, KeyAPIKey, "secret-1234")
+		})
+	})
+
+	if stderr != "" {
+		t.Errorf("wrote %q to stderr, want stdout only", stderr)
+	}
+	for _, want := range []string{"DEBUG", "logger_test.go:", "hello", `"` + KeyXRequestID + `": "req-1"`, `"tenant": "acme"`, `"user_id": 1`, `"api-key": "****1234"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q doesn't contain %q", out, want)
+		}
+	}
+}
+
+func TestWithField(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.WithField("tenant", "acme").WithField("user_id", 7).WithField("timeout", 2*time.Second).Info("hello")
+
+	want := map[string]interface{}{"tenant": "acme", "user_id": int64(7), "timeout": 2000.0}
+	if got := logs.All()[0].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestNewObserved(t *testing.T) {
+	l, logs := NewObserved(context.Background())
+	l.WithContext(ContextWithRequestID(context.Background(), "req-1")).Debug("hello", "user_id", 1)
+
+	entries := logs.FilterMessage("hello").All()
+	if len(entries) != 1 {
+		t.Fatalf("observed %d entries, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Level != zapcore.DebugLevel {
+		t.Errorf("level = %v, want debug", entry.Level)
+	}
+	want := map[string]interface{}{KeyXRequestID: "req-1", "user_id": int64(1)}
+	if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	if !strings.HasSuffix(entry.Caller.File, "logger_test.go") {
+		t.Errorf("caller = %s, want the log site", entry.Caller)
+	}
+}
+
+func TestPanic(t *testing.T) {
+	tests := []struct {
+		name  string
+		level LevelError
+		want  int
+	}{
+		{"enabled", InfoLevel, 1},
+		{"above level", FatalLevel, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			if err := l.SetLevel(tt.level); err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				if r := recover(); r != "boom" {
+					t.Errorf("recovered %v, want boom", r)
+				}
+				if n := logs.FilterLevelExact(zapcore.PanicLevel).Len(); n != tt.want {
+					t.Errorf("logged %d panic entries, want %d", n, tt.want)
+				}
+			}()
+			l.Panic("boom", "k", "v")
+			t.Error("Panic returned")
+		})
+	}
+}
+
+func TestDPanic(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.DPanic("production")
+	if n := logs.FilterLevelExact(zapcore.DPanicLevel).Len(); n != 1 {
+		t.Errorf("logged %d dpanic entries, want 1", n)
+	}
+
+	panicked := false
+	captureStderr(t, func() {
+		defer func() { panicked = recover() != nil }()
+		NewDevelopment(nil).DPanic("development")
+	})
+	if !panicked {
+		t.Error("DPanic didn't panic on a development logger")
+	}
+}
+
+func TestNewProductionBuffered(t *testing.T) {
+	captureStderr(t, func() {
+		l := NewProductionBuffered(nil, time.Hour)
+		l.Info("buffered")
+		written := func() string {
+			b, err := os.ReadFile(os.Stderr.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(b)
+		}
+		if out := written(); out != "" {
+			t.Errorf("wrote %q before Sync", out)
+		}
+		_ = l.Sync()
+		if out := written(); !strings.Contains(out, "buffered") {
+			t.Errorf("wrote %q after Sync, want the entry", out)
+		}
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	})
+}
+
+func TestIsStdSyncError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"stderr EINVAL", &fs.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.EINVAL}, true},
+		{"stdout ENOTTY", &fs.PathError{Op: "sync", Path: os.Stdout.Name(), Err: syscall.ENOTTY}, true},
+		{"wrapped", fmt.Errorf("flush: %w", &fs.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.EINVAL}), true},
+		{"stderr EIO", &fs.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.EIO}, false},
+		{"file EINVAL", &fs.PathError{Op: "sync", Path: "/var/log/app.log", Err: syscall.EINVAL}, false},
+		{"other", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := isStdSyncError(tt.err); got != tt.want {
+			t.Errorf("%s: isStdSyncError() = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+// syncErrWriter is a zapcore.WriteSyncer failing to sync.
+type syncErrWriter struct {
+	bytes.Buffer
+	err error
+}
+
+func (w *syncErrWriter) Sync() error { return w.err }
+
+func TestClose(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{"stderr", &fs.PathError{Op: "sync", Path: os.Stderr.Name(), Err: syscall.EINVAL}, false},
+		{"file", &fs.PathError{Op: "sync", Path: "/var/log/app.log", Err: syscall.EIO}, true},
+		{"none", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewProductionWithWriter(&syncErrWriter{err: tt.err}, nil)
+			if err := l.Close(); (err != nil) != tt.wantErr {
+				t.Errorf("Close() = %v, want error %t", err, tt.wantErr)
+			}
+			if err := l.Sync(); !errors.Is(err, tt.err) {
+				t.Errorf("Sync() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestNewProductionFile(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	l := NewProductionFile(nil, path, 1, 2, 0)
+	pad := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		l.Info("hello", "pad", pad)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := decodeEntries(t, string(out)); len(entries) == 0 || entries[0]["message"] != "hello" {
+		t.Errorf("file holds %d entries, want the logged ones", len(entries))
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Errorf("dir holds %d files, want the log file and one backup", len(files))
+	}
+}
+
+func TestCtxMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*Logger, context.Context, string, ...interface{})
+		want zapcore.Level
+	}{
+		{"ErrorCtx", (*Logger).ErrorCtx, zapcore.ErrorLevel},
+		{"WarnCtx", (*Logger).WarnCtx, zapcore.WarnLevel},
+		{"InfoCtx", (*Logger).InfoCtx, zapcore.InfoLevel},
+		{"DebugCtx", (*Logger).DebugCtx, zapcore.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := ContextWithSessionID(ContextWithRequestID(context.Background(), "base"), "session-1")
+			l, logs := NewObserved(base)
+			tt.log(l, ContextWithRequestID(context.Background(), "entry"), "hello", "k", "v")
+			l.Info("after")
+
+			entries := logs.All()
+			if entries[0].Level != tt.want {
+				t.Errorf("level = %v, want %v", entries[0].Level, tt.want)
+			}
+			want := map[string]interface{}{KeyXRequestID: "entry", KeySessionID: "session-1", "k": "v"}
+			if got := entries[0].ContextMap(); !reflect.DeepEqual(got, want) {
+				t.Errorf("fields = %v, want %v", got, want)
+			}
+			if got := entries[1].ContextMap()[KeyXRequestID]; got != "base" {
+				t.Errorf("next entry %s = %v, want base", KeyXRequestID, got)
+			}
+		})
+	}
+}
+
+func TestInfoJSONAllowList(t *testing.T) {
+	value := map[string]interface{}{"id": 1, "name": "ana", "password": "secret", "card": "4111"}
+	tests := []struct {
+		name  string
+		extra LoggerExtras
+		want  map[string]interface{}
+	}{
+		{
+			name:  "no allow list",
+			extra: LoggerExtras{Key: "user", Value: map[string]interface{}{"id": 1}},
+			want:  map[string]interface{}{"id": 1},
+		},
+		{
+			name:  "allow list",
+			extra: LoggerExtras{Key: "user", Value: value, AllowList: []string{"id", "name", "missing"}},
+			want:  map[string]interface{}{"id": 1, "name": "ana"},
+		},
+		{
+			name:  "allow list and filter",
+			extra: LoggerExtras{Key: "user", Value: value, AllowList: []string{"id", "card"}, Filter: []string{"card"}},
+			want:  map[string]interface{}{"id": 1, "card": redactedValue},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			l.InfoJSON("user", `{}`, tt.extra)
+
+			if got := logs.All()[0].ContextMap()["user"]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("user = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProductionWithFatalHook(t *testing.T) {
+	calls := 0
+	out := captureStderr(t, func() {
+		l := NewProductionWithFatalHook(nil, func() { calls++ })
+		l.Fatal("fatal", "k", "v")
+		l.FatalFields("fatal fields")
+	})
+
+	if calls != 2 {
+		t.Errorf("hook called %d times, want 2", calls)
+	}
+	entries := decodeEntries(t, out)
+	if len(entries) != 2 || entries[0]["level"] != "fatal" || entries[0]["k"] != "v" {
+		t.Errorf("entries = %v, want both fatal entries", entries)
+	}
+}
+
+func TestInfoEach(t *testing.T) {
+	l, logs := NewObserved(ContextWithRequestID(context.Background(), "req-1"))
+	items := []map[string]interface{}{{"id": 1}, {"id": 2}}
+	l.InfoEach("item", items)
+
+	entries := logs.All()
+	if len(entries) != len(items) {
+		t.Fatalf("logged %d entries, want %d", len(entries), len(items))
+	}
+	for i, entry := range entries {
+		want := map[string]interface{}{KeyXRequestID: "req-1", "item": items[i]}
+		if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+			t.Errorf("entry %d fields = %v, want %v", i, got, want)
+		}
+		if !strings.HasSuffix(entry.Caller.File, "logger_test.go") {
+			t.Errorf("entry %d caller = %s, want the log site", i, entry.Caller)
+		}
+	}
+
+	if err := l.SetLevel(WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.InfoEach("item", items)
+	if n := logs.Len(); n != len(items) {
+		t.Errorf("logged %d entries, want none once InfoLevel is disabled", n-len(items))
+	}
+}
+
+func TestWithContextConcurrent(t *testing.T) {
+	l, logs := NewObserved(ContextWithRequestID(context.Background(), "base"))
+	const n = 50
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+			child := l.WithContext(ContextWithRequestID(context.Background(), id))
+			child.Info("child", "want", id)
+			l.Info("parent")
+		}(i)
+	}
+	wg.Wait()
+
+	for _, entry := range logs.FilterMessage("child").All() {
+		fields := entry.ContextMap()
+		if fields[KeyXRequestID] != fields["want"] {
+			t.Errorf("%s = %v, want %v", KeyXRequestID, fields[KeyXRequestID], fields["want"])
+		}
+	}
+	for _, entry := range logs.FilterMessage("parent").All() {
+		if got := entry.ContextMap()[KeyXRequestID]; got != "base" {
+			t.Errorf("parent %s = %v, want base", KeyXRequestID, got)
+		}
+	}
+}
+
+func TestFieldsMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*Logger, string, ...zap.Field)
+		want zapcore.Level
+	}{
+		{"ErrorFields", (*Logger).ErrorFields, zapcore.ErrorLevel},
+		{"WarnFields", (*Logger).WarnFields, zapcore.WarnLevel},
+		{"InfoFields", (*Logger).InfoFields, zapcore.InfoLevel},
+		{"DebugFields", (*Logger).DebugFields, zapcore.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(ContextWithRequestID(context.Background(), "req-1"))
+			tt.log(l, "hello", zap.Int("user_id", 1), zap.Bool("ok", true))
+
+			entry := logs.All()[0]
+			if entry.Level != tt.want {
+				t.Errorf("level = %v, want %v", entry.Level, tt.want)
+			}
+			want := map[string]interface{}{KeyXRequestID: "req-1", "user_id": int64(1), "ok": true}
+			if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+				t.Errorf("fields = %v, want %v", got, want)
+			}
+			if !strings.HasSuffix(entry.Caller.File, "logger_test.go") {
+				t.Errorf("caller = %s, want the log site", entry.Caller)
+			}
+		})
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	l := NewProductionWithWriter(io.Discard, context.Background())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.Info("hello", "user_id", i, "tenant", "acme")
+	}
+}
+
+func BenchmarkInfoFields(b *testing.B) {
+	l := NewProductionWithWriter(io.Discard, context.Background())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l.InfoFields("hello", zap.Int("user_id", i), zap.String("tenant", "acme"))
+	}
+}
+
+// fakeClock is a Clock returning a time set by the test.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTimer(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)}
+	l, logs := NewObserved(nil)
+	done := l.WithClock(clock).Timer()
+	clock.Add(1500 * time.Millisecond)
+	done("handler done", "route", "/orders")
+
+	entry := logs.All()[0]
+	if entry.Message != "handler done" || entry.Level != zapcore.InfoLevel {
+		t.Errorf("entry = %q at %v", entry.Message, entry.Level)
+	}
+	want := map[string]interface{}{"duration_ms": 1500.0, "route": "/orders"}
+	if got := entry.ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestNewProductionISO8601(t *testing.T) {
+	out := captureStderr(t, func() {
+		NewProductionISO8601(nil).Info("hello")
+	})
+
+	ts, ok := decodeEntries(t, out)[0]["ts"].(string)
+	if !ok {
+		t.Fatalf("ts isn't a string: %s", out)
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000Z0700", ts); err != nil {
+		t.Errorf("ts %q isn't ISO8601: %v", ts, err)
+	}
+}
+
+func TestNewProductionWithKeys(t *testing.T) {
+	out := captureStderr(t, func() {
+		NewProductionWithKeys(nil, EncoderKeys{LevelKey: "severity", MessageKey: "msg"}).Info("hello")
+	})
+
+	entry := decodeEntries(t, out)[0]
+	for _, key := range []string{"severity", "msg", "ts", "caller"} {
+		if _, ok := entry[key]; !ok {
+			t.Errorf("entry %v has no %q key", entry, key)
+		}
+	}
+	for _, key := range []string{"level", "message"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("entry %v still has the %q key", entry, key)
+		}
+	}
+}
+
+func TestIfMethods(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*Logger, bool, string, ...interface{})
+		want zapcore.Level
+	}{
+		{"ErrorIf", (*Logger).ErrorIf, zapcore.ErrorLevel},
+		{"WarnIf", (*Logger).WarnIf, zapcore.WarnLevel},
+		{"InfoIf", (*Logger).InfoIf, zapcore.InfoLevel},
+		{"DebugIf", (*Logger).DebugIf, zapcore.DebugLevel},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			tt.log(l, false, "skipped")
+			tt.log(l, true, "logged", "k", "v")
+
+			entries := logs.All()
+			if len(entries) != 1 || entries[0].Message != "logged" || entries[0].Level != tt.want {
+				t.Fatalf("entries = %v, want the logged one at %v", entries, tt.want)
+			}
+			if !strings.HasSuffix(entries[0].Caller.File, "logger_test.go") {
+				t.Errorf("caller = %s, want the log site", entries[0].Caller)
+			}
+		})
+	}
+}
+
+func TestInfoIfFalseAllocs(t *testing.T) {
+	l := NewProductionWithWriter(io.Discard, ContextWithRequestID(context.Background(), "req-1"))
+	allocs := testing.AllocsPerRun(100, func() {
+		l.InfoIf(false, "skipped")
+	})
+	if allocs != 0 {
+		t.Errorf("InfoIf(false) allocates %v times, want 0", allocs)
+	}
+}
+
+func BenchmarkInfoIf(b *testing.B) {
+	l := NewProductionWithWriter(io.Discard, context.Background())
+	for _, cond := range []bool{false, true} {
+		b.Run(strconv.FormatBool(cond), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.InfoIf(cond, "hello", "user_id", 1)
+			}
+		})
+	}
+}
+
+func TestWithRequestID(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.WithRequestID("req-1").WithField("tenant", "acme").Info("hello")
+	l.Info("parent")
+
+	entries := logs.All()
+	want := map[string]interface{}{KeyXRequestID: "req-1", "tenant": "acme"}
+	if got := entries[0].ContextMap(); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+	if _, ok := entries[1].ContextMap()[KeyXRequestID]; ok {
+		t.Error("parent entry carries the request id")
+	}
+}
+
+func TestWarnOnce(t *testing.T) {
+	l, logs := NewObserved(nil)
+	other, otherLogs := NewObserved(nil)
+	key := t.Name()
+	l.WarnOnce(key, "deprecated", "n", 1)
+	l.WarnOnce(key, "deprecated", "n", 2)
+	other.WarnOnce(key, "deprecated", "n", 3)
+	l.WarnOnce(key+"/other", "deprecated", "n", 4)
+
+	entries := logs.All()
+	if len(entries) != 2 || entries[0].ContextMap()["n"] != int64(1) || entries[1].ContextMap()["n"] != int64(4) {
+		t.Errorf("entries = %v, want the first one of each key", entries)
+	}
+	if n := otherLogs.Len(); n != 0 {
+		t.Errorf("another logger logged %d entries for a seen key, want none", n)
+	}
+}
+
+func TestWarnOnceDisabled(t *testing.T) {
+	l, logs := NewObserved(nil)
+	key := t.Name()
+	if err := l.SetLevel(ErrorLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.WarnOnce(key, "deprecated")
+	if err := l.SetLevel(WarnLevel); err != nil {
+		t.Fatal(err)
+	}
+	l.WarnOnce(key, "deprecated")
+
+	if n := logs.Len(); n != 1 {
+		t.Errorf("logged %d entries, want the warning once the level allows it", n)
+	}
+}
+
+func TestFields(t *testing.T) {
+	t.Setenv("SERVICE_NAME", "payments")
+	l, logs := NewObserved(ContextWithRequestID(context.Background(), "req-1"))
+	l = l.With(zap.String("bound", "x"))
+
+	want := map[string]interface{}{KeyXRequestID: "req-1", KeyService: "payments"}
+	if got := fieldMap(l.Fields()); !reflect.DeepEqual(got, want) {
+		t.Errorf("Fields() = %v, want %v", got, want)
+	}
+	if n := logs.Len(); n != 0 {
+		t.Errorf("Fields logged %d entries, want none", n)
+	}
+}
+
+func TestNewProductionTee(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	path := filepath.Join(t.TempDir(), "app.log")
+	out := captureStderr(t, func() {
+		l, err := NewProductionTee(nil, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l.Info("hello")
+		if err := l.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	})
+
+	file, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(file) != out || len(decodeEntries(t, out)) != 1 {
+		t.Errorf("file holds %q and stderr %q, want the same entry", file, out)
+	}
+}
+
+func TestNewProductionTeeError(t *testing.T) {
+	if _, err := NewProductionTee(nil, filepath.Join(t.TempDir(), "missing", "app.log")); err == nil {
+		t.Error("NewProductionTee didn't fail on a missing directory")
+	}
+}
+
+// mockLogger is a LoggerIface recording the messages logged, as a consumer's
+// test would use it.
+type mockLogger struct {
+	messages []string
+}
+
+func (m *mockLogger) record(level, msg string) { m.messages = append(m.messages, level+": "+msg) }
+
+func (m *mockLogger) Fatal(msg string, _ ...interface{})     { m.record("fatal", msg) }
+func (m *mockLogger) Error(msg string, _ ...interface{})     { m.record("error", msg) }
+func (m *mockLogger) Warn(msg string, _ ...interface{})      { m.record("warn", msg) }
+func (m *mockLogger) Info(msg string, _ ...interface{})      { m.record("info", msg) }
+func (m *mockLogger) Debug(msg string, _ ...interface{})     { m.record("debug", msg) }
+func (m *mockLogger) InfoJSON(msg, _ string, _ LoggerExtras) { m.record("info", msg) }
+func (m *mockLogger) With(...zap.Field) *Logger              { return NewNop() }
+func (m *mockLogger) Sync() error                            { return nil }
+
+// chargeOrder is code depending on a LoggerIface.
+func chargeOrder(l LoggerIface, amount int) error {
+	if amount <= 0 {
+		l.Warn("invalid amount", "amount", amount)
+		return errors.New("invalid amount")
+	}
+	l.Info("order charged", "amount", amount)
+	return nil
+}
+
+func TestLoggerIfaceMock(t *testing.T) {
+	m := &mockLogger{}
+	_ = chargeOrder(m, 10)
+	_ = chargeOrder(m, 0)
+
+	want := []string{"info: order charged", "warn: invalid amount"}
+	if !reflect.DeepEqual(m.messages, want) {
+		t.Errorf("messages = %v, want %v", m.messages, want)
+	}
+	if err := chargeOrder(NewNop(), 10); err != nil {
+		t.Errorf("chargeOrder(NewNop()) = %v", err)
+	}
+}
+
+func TestNewLoggerFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		wantJSON bool
+	}{
+		{"default", "", true},
+		{"json", "json", true},
+		{"console", "console", false},
+		{"console uppercase", "CONSOLE", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_FORMAT", tt.format)
+			t.Setenv("LOG_HOST_PID", "false")
+			out := captureStderr(t, func() {
+				NewLogger(nil).Info("hello", "user_id", 1)
+			})
+
+			if got := json.Valid([]byte(out)); got != tt.wantJSON {
+				t.Errorf("output %q is JSON %t, want %t", out, got, tt.wantJSON)
+			}
+			if !tt.wantJSON && !strings.Contains(out, "\tinfo\t") {
+				t.Errorf("output %q isn't in the console format", out)
+			}
+		})
+	}
+}
+
+func TestInfoJSONUnserializable(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var buf bytes.Buffer
+	l := NewProductionWithWriter(&buf, nil)
+	l.InfoJSON("user", `{"id":1}`, LoggerExtras{Key: "extra", Value: map[string]interface{}{
+		"ch":     make(chan int),
+		"fn":     func() {},
+		"nested": map[string]interface{}{"ok": "yes", "bad": []interface{}{make(chan string)}},
+		"num":    1.5,
+	}})
+
+	entries := decodeEntries(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1: %s", len(entries), buf.String())
+	}
+	want := map[string]interface{}{
+		"ch":     "chan int",
+		"fn":     "func()",
+		"nested": map[string]interface{}{"ok": "yes", "bad": []interface{}{"chan string"}},
+		"num":    1.5,
+	}
+	if got := entries[0]["extra"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("extra = %v, want %v", got, want)
+	}
+}
+
+func TestProcessFields(t *testing.T) {
+	host, _ := os.Hostname()
+	constructors := map[string]func() *Logger{
+		"NewProduction": func() *Logger { return NewProduction(nil) },
+		"NewLogger":     func() *Logger { return NewLogger(nil) },
+	}
+	for name, newLogger := range constructors {
+		for _, env := range []string{"", "false"} {
+			t.Run(name+"/LOG_HOST_PID="+env, func(t *testing.T) {
+				t.Setenv("LOG_LEVEL", "")
+				t.Setenv("LOG_FORMAT", "")
+				t.Setenv("LOG_HOST_PID", env)
+				out := captureStderr(t, func() {
+					l := newLogger()
+					l.Info("hello")
+					_ = l.Sync()
+				})
+
+				entries := decodeEntries(t, out)
+				if len(entries) != 1 {
+					t.Fatalf("logged %d entries, want 1: %s", len(entries), out)
+				}
+				entry := entries[0]
+				if env == "false" {
+					if _, ok := entry["host"]; ok {
+						t.Errorf("entry = %v, want no host", entry)
+					}
+					if _, ok := entry["pid"]; ok {
+						t.Errorf("entry = %v, want no pid", entry)
+					}
+					return
+				}
+				if entry["host"] != host || entry["pid"] != float64(os.Getpid()) {
+					t.Errorf("entry = %v, want host %q and pid %d", entry, host, os.Getpid())
+				}
+			})
+		}
+	}
+}
+
+func TestBuildLoggerFallback(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	if l, err := NewProductionE(nil); l == nil || err != nil {
+		t.Fatalf("NewProductionE() = %v, %v", l, err)
+	}
+	cf := productionConfig()
+	cf.OutputPaths = []string{filepath.Join(t.TempDir(), "missing", "app.log")}
+	if l, err := buildLoggerE(nil, cf); l != nil || err == nil {
+		t.Fatalf("buildLoggerE() = %v, %v, want an error", l, err)
+	}
+
+	buildFailed = sync.Once{}
+	out := captureStderr(t, func() {
+		l := buildLogger(nil, cf)
+		l.Info("still logging")
+		_ = l.Sync()
+		buildLogger(nil, cf)
+	})
+	warning, rest, _ := strings.Cut(out, "\n")
+	if !strings.Contains(warning, "qlog: build logger") || !strings.HasSuffix(warning, "logging to stderr instead") {
+		t.Errorf("warning = %q, want the build error", warning)
+	}
+	entries := decodeEntries(t, rest)
+	if len(entries) != 1 || entries[0]["message"] != "still logging" {
+		t.Errorf("entries = %v, want the entry written to stderr, warned once", entries)
+	}
+}
+
+func TestInfoJSONPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		jbs  string
+		want string
+	}{
+		{"object", `{"id":12345678901234567890,"tags":["a"]}`, `{"id":12345678901234567890,"tags":["a"]}`},
+		{"array", `[{"id":1},{"id":2}]`, `[{"id":1},{"id":2}]`},
+		{"invalid", `{"id":`, `"{\"id\":"`},
+		{"trailing data", `{} {}`, `"{} {}"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", "")
+			var buf bytes.Buffer
+			NewProductionWithWriter(&buf, nil).InfoJSON("orders", tt.jbs, LoggerExtras{})
+
+			var entry map[string]json.RawMessage
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("decode %q: %v", buf.String(), err)
+			}
+			if got := string(entry[payloadKey]); got != tt.want {
+				t.Errorf("%s = %s, want %s", payloadKey, got, tt.want)
+			}
+			if got := string(entry["message"]); got != `"orders"` {
+				t.Errorf("message = %s, want the message alone", got)
+			}
+		})
+	}
+}
+
+func TestNewProductionSplit(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var stdout string
+	stderr := captureStderr(t, func() {
+		stdout = captureStdout(t, func() {
+			l := NewProductionSplit(nil)
+			l.Debug("hidden")
+			l.Info("info")
+			l.Warn("warn")
+			l.Error("error")
+			_ = l.Sync()
+		})
+	})
+
+	for _, tt := range []struct {
+		name string
+		out  string
+		want []string
+	}{
+		{"stdout", stdout, []string{"info", "warn"}},
+		{"stderr", stderr, []string{"error"}},
+	} {
+		var got []string
+		for _, entry := range decodeEntries(t, tt.out) {
+			got = append(got, entry["message"].(string))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s entries = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSplitWrappedCores(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var out, errOut bytes.Buffer
+	l := newSplitLogger(nil, zapcore.AddSync(&out), zapcore.AddSync(&errOut)).
+		WithDedup(time.Minute).
+		WithTrimmedStacktrace("github.com/correctinho/correct-mlt-go/qlog.TestSplitWrappedCores").
+		With(zap.String("tenant", "acme"))
+	l.Info("info")
+	l.Error("error")
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name string
+		out  string
+		want string
+	}{
+		{"out", out.String(), "info"},
+		{"errOut", errOut.String(), "error"},
+	} {
+		entries := decodeEntries(t, tt.out)
+		if len(entries) != 1 || entries[0]["message"] != tt.want || entries[0]["tenant"] != "acme" {
+			t.Errorf("%s entries = %v, want the %s entry once", tt.name, entries, tt.want)
+		}
+	}
+	if _, ok := decodeEntries(t, errOut.String())[0]["stack"]; !ok {
+		t.Error("error entry has no trimmed stack")
+	}
+}
+
+func TestCheckDeadline(t *testing.T) {
+	now := time.Date(2099, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name     string
+		left     time.Duration
+		deadline bool
+		want     interface{}
+	}{
+		{"far", 5 * time.Second, true, nil},
+		{"near", 500 * time.Millisecond, true, 500.0},
+		{"passed", -200 * time.Millisecond, true, -200.0},
+		{"no deadline", 0, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := ContextWithRequestID(context.Background(), "req-1")
+			if tt.deadline {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithDeadline(ctx, now.Add(tt.left))
+				defer cancel()
+			}
+			base, logs := NewObserved(nil)
+			base.WithClock(&fakeClock{now: now}).CheckDeadline(ctx, time.Second)
+
+			if tt.want == nil {
+				if n := logs.Len(); n != 0 {
+					t.Errorf("logged %d entries, want none", n)
+				}
+				return
+			}
+			entry := logs.All()[0]
+			if entry.Message != "context deadline near" || entry.Level != zapcore.WarnLevel {
+				t.Errorf("entry = %q at %v, want context deadline near at warn", entry.Message, entry.Level)
+			}
+			fields := entry.ContextMap()
+			if fields["remaining_ms"] != tt.want || fields[KeyXRequestID] != "req-1" {
+				t.Errorf("fields = %v, want remaining_ms %v and the request id", fields, tt.want)
+			}
+			if got, _ := fields["deadline"].(time.Time); !got.Equal(now.Add(tt.left)) {
+				t.Errorf("deadline = %v, want %v", fields["deadline"], now.Add(tt.left))
+			}
+			if !strings.HasSuffix(entry.Caller.File, "logger_test.go") {
+				t.Errorf("caller = %s, want the CheckDeadline call site", entry.Caller)
+			}
+		})
+	}
+}
+
+func TestFromCtx(t *testing.T) {
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	l := FromCtx(ctx)
+	if l.Context != ctx {
+		t.Error("FromCtx() isn't bound to ctx")
+	}
+	if got := fieldMap(l.logFromContext(l.Context))[KeyXRequestID]; got != "req-1" {
+		t.Errorf("%s = %v, want req-1", KeyXRequestID, got)
+	}
+
+	other := FromCtx(context.Background())
+	if other.Zap != l.Zap {
+		t.Error("FromCtx() built a new zap logger, want the shared one")
+	}
+	level := l.GetLevel()
+	t.Cleanup(func() { _ = l.SetLevel(level) })
+	if err := l.SetLevel(DebugLevel); err != nil {
+		t.Fatal(err)
+	}
+	if !other.DebugEnabled() {
+		t.Error("SetLevel() on a FromCtx Logger doesn't apply to the others")
+	}
+}
+
+func BenchmarkFromCtx(b *testing.B) {
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FromCtx(ctx)
+	}
+}
+
+func TestGoDebugExplicitOutput(t *testing.T) {
+	t.Setenv("GO_DEBUG", "1")
+	t.Setenv("LOG_LEVEL", "")
+	var buf bytes.Buffer
+	var logs interface{ Len() int }
+	out := captureStdout(t, func() {
+		observed, ol := NewObserved(nil)
+		observed.Info("observed")
+		logs = ol
+
+		l := NewProductionWithWriter(&buf, nil)
+		l.Debug("hidden")
+		l.Info("written")
+	})
+
+	if out != "" {
+		t.Errorf("stdout = %q, want nothing", out)
+	}
+	if n := logs.Len(); n != 1 {
+		t.Errorf("observed %d entries, want 1", n)
+	}
+	if entries := decodeEntries(t, buf.String()); len(entries) != 1 || entries[0]["message"] != "written" {
+		t.Errorf("writer holds %s, want the info entry only", buf.String())
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	errDisk := errors.New("disk full")
+	tests := []struct {
+		name string
+		log  func()
+	}{
+		{"writer", func() {
+			NewProductionWithWriter(failingWriter{errDisk}, nil).Info("info")
+		}},
+		{"wrapped split", func() {
+			l := newSplitLogger(nil, zapcore.AddSync(&bytes.Buffer{}), zapcore.AddSync(failingWriter{errDisk}))
+			l.WithTrimmedStacktrace("github.com/correctinho").With(zap.String("tenant", "acme")).Error("error")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := captureStderr(t, tt.log)
+			if n := strings.Count(out, "write error: disk full\n"); n != 1 {
+				t.Errorf("stderr = %q, want the write error reported once", out)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package qlog
+
+import (
+	"context"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+	"go.uber.org/zap"
+)
+
+// Keys of the New Relic linking metadata, as expected by Logs in Context.
+const (
+	KeyNewRelicTraceID    = "trace.id"
+	KeyNewRelicSpanID     = "span.id"
+	KeyNewRelicEntityName = "entity.name"
+	KeyNewRelicEntityGUID = "entity.guid"
+)
+
+// WithNewRelic returns a child logger adding to every entry the linking
+// metadata of the newrelic.Transaction found on the context.Context of
+// Context, e.g. set by the New Relic gin or net/http integrations, so the
+// entries are correlated with their trace in New Relic's Logs in Context.
+// Entries logged without a transaction are not affected.
+func (l *Logger) WithNewRelic() *Logger {
+	child := *l
+	child.newRelic = true
+	return &child
+}
+
+// newRelicFields returns the linking metadata fields of the transaction on
+// ctx, if any.
+func newRelicFields(ctx context.Context) (fields []zap.Field) {
+	if ctx == nil {
+		return nil
+	}
+	txn := newrelic.FromContext(ctx)
+	if txn == nil {
+		return nil
+	}
+	md := txn.GetLinkingMetadata()
+	for _, f := range []struct{ key, value string }{
+		{KeyNewRelicTraceID, md.TraceID},
+		{KeyNewRelicSpanID, md.SpanID},
+		{KeyNewRelicEntityName, md.EntityName},
+		{KeyNewRelicEntityGUID, md.EntityGUID},
+	} {
+		if f.value != "" {
+			fields = append(fields, zap.String(f.key, f.value))
+		}
+	}
+	return fields
+}
@@ -0,0 +1,61 @@
+package qlog
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/newrelic/go-agent/v3/newrelic"
+)
+
+func TestWithNewRelic(t *testing.T) {
+	app, err := newrelic.NewApplication(
+		newrelic.ConfigAppName("qlog-test"),
+		newrelic.ConfigLicense("0123456789012345678901234567890123456789"),
+		newrelic.ConfigEnabled(false),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txn := app.StartTransaction("checkout")
+	defer txn.End()
+	md := txn.GetLinkingMetadata()
+	ctx := newrelic.NewContext(context.Background(), txn)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+
+	contexts := []struct {
+		name string
+		ctx  interface{}
+	}{
+		{"context.Context", ctx},
+		{"*http.Request", req},
+		{"*gin.Context", newGinContext(req)},
+	}
+	for _, tt := range contexts {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(tt.ctx)
+			l.WithNewRelic().Info("with")
+			l.Info("without")
+
+			fields := logs.All()[0].ContextMap()
+			if fields[KeyNewRelicTraceID] != md.TraceID || fields[KeyNewRelicEntityName] != "qlog-test" {
+				t.Errorf("fields = %v, want the linking metadata", fields)
+			}
+			if _, ok := logs.All()[1].ContextMap()[KeyNewRelicTraceID]; ok {
+				t.Error("entry logged without WithNewRelic has the linking metadata")
+			}
+		})
+	}
+}
+
+func TestWithNewRelicNoTransaction(t *testing.T) {
+	l, logs := NewObserved(context.Background())
+	l.WithNewRelic().Info("hello")
+	l.WithNewRelic().WithContext(nil).Info("nil")
+
+	for _, entry := range logs.All() {
+		if _, ok := entry.ContextMap()[KeyNewRelicTraceID]; ok {
+			t.Errorf("%s: entry without transaction has the linking metadata", entry.Message)
+		}
+	}
+}
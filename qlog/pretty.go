@@ -0,0 +1,60 @@
+package qlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// prettyBuffers holds the buffers returned by prettyEncoder.
+var prettyBuffers = buffer.NewPool()
+
+// prettyEncoder is a JSON encoder writing each entry as indented, multi-line
+// JSON.
+type prettyEncoder struct {
+	zapcore.Encoder
+}
+
+// Clone implements zapcore.Encoder.
+func (e prettyEncoder) Clone() zapcore.Encoder {
+	return prettyEncoder{e.Encoder.Clone()}
+}
+
+// EncodeEntry implements zapcore.Encoder, indenting the JSON encoded entry.
+func (e prettyEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	buf, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer buf.Free()
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bytes.TrimSpace(buf.Bytes()), "", "  "); err != nil {
+		return nil, err
+	}
+	out := prettyBuffers.Get()
+	out.Write(indented.Bytes())
+	out.AppendByte('\n')
+	return out, nil
+}
+
+// prettyCore returns a core writing indented JSON entries to ws, with
+// readable timestamps.
+func prettyCore(ws zapcore.WriteSyncer, level zapcore.LevelEnabler) zapcore.Core {
+	ec := productionEncoderConfig()
+	ec.EncodeTime = zapcore.ISO8601TimeEncoder
+	return zapcore.NewCore(prettyEncoder{zapcore.NewJSONEncoder(ec)}, ws, level)
+}
+
+// NewDevelopmentPretty builds a development Logger that writes DebugLevel and
+// above logs to standard error as indented, multi-line JSON, so nested data
+// stays readable. It is meant for local debugging only; the output can't be
+// parsed line by line and must not be used in production.
+func NewDevelopmentPretty(context interface{}) *Logger {
+	level := zap.NewAtomicLevelAt(zapcore.DebugLevel)
+	return newLogger(context, prettyCore(zapcore.Lock(os.Stderr), level), level, zap.Development())
+}
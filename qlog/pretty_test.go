@@ -0,0 +1,41 @@
+package qlog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDevelopmentPretty(t *testing.T) {
+	out := captureStderr(t, func() {
+		NewDevelopmentPretty(nil).Debug("hello", "user", map[string]interface{}{"id": 1})
+	})
+
+	if !strings.Contains(out, "\n  \"message\": \"hello\",\n") {
+		t.Errorf("output %q isn't indented", out)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &entry); err != nil {
+		t.Fatalf("decode %q: %v", out, err)
+	}
+	if _, err := time.Parse("2006-01-02T15:04:05.000Z0700", entry["ts"].(string)); err != nil {
+		t.Errorf("ts isn't readable: %v", err)
+	}
+	if user, _ := entry["user"].(map[string]interface{}); user["id"] != 1.0 {
+		t.Errorf("user = %v, want the nested object", entry["user"])
+	}
+}
+
+func TestGoDebugPretty(t *testing.T) {
+	t.Setenv("GO_DEBUG_PRETTY", "1")
+	t.Setenv("LOG_HOST_PID", "false")
+	out := captureStdout(t, func() {
+		NewNop().Info("nop")
+		NewProduction(nil).Debug("hello")
+	})
+
+	if !strings.Contains(out, "\n  \"message\": \"hello\"\n") || strings.Contains(out, "nop") {
+		t.Errorf("stdout = %q, want the indented debug entry only", out)
+	}
+}
@@ -0,0 +1,33 @@
+package qlog
+
+import (
+	"encoding/json"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Proto constructs a field logging m, marshaled with protojson, as a nested
+// JSON object, so gRPC requests and responses stay readable. With the
+// MaxFieldBytes option, a message longer than the limit is logged as a
+// truncated string. When m can't be marshaled the error is logged under
+// key+"Error" instead.
+func Proto(key string, m proto.Message) zap.Field {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return zap.String(key+"Error", err.Error())
+	}
+	return zap.Reflect(key, json.RawMessage(b))
+}
+
+// ProtoJSON returns m marshaled with protojson, to be passed as the JSON of
+// InfoJSON and its siblings. It returns an empty string, so only the message
+// is logged, when m can't be marshaled.
+func ProtoJSON(m proto.Message) string {
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
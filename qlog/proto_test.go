@@ -0,0 +1,52 @@
+package qlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProto(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	msg, err := structpb.NewStruct(map[string]interface{}{"id": 1, "name": "acme"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	l := NewProductionWithWriter(&buf, nil)
+	l.Info("request", Proto("request", msg), Proto("invalid", wrapperspb.String("\xff")))
+
+	entries := decodeEntries(t, buf.String())
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1: %s", len(entries), buf.String())
+	}
+	entry := entries[0]
+	want := map[string]interface{}{"id": 1.0, "name": "acme"}
+	if got := entry["request"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("request = %v, want %v", got, want)
+	}
+	if _, ok := entry["invalid"]; ok {
+		t.Errorf("invalid = %v, want the marshaling error", entry["invalid"])
+	}
+	if got, _ := entry["invalidError"].(string); got == "" {
+		t.Errorf("invalidError = %v, want the marshaling error", entry["invalidError"])
+	}
+}
+
+func TestProtoJSON(t *testing.T) {
+	if got := ProtoJSON(wrapperspb.String("acme")); got != `"acme"` {
+		t.Errorf("ProtoJSON(StringValue) = %s, want \"acme\"", got)
+	}
+	var got map[string]interface{}
+	msg, _ := structpb.NewStruct(map[string]interface{}{"name": "acme"})
+	if err := json.Unmarshal([]byte(ProtoJSON(msg)), &got); err != nil || got["name"] != "acme" {
+		t.Errorf("ProtoJSON(Struct) = %v, %v, want the message as JSON", got, err)
+	}
+	if got := ProtoJSON(wrapperspb.String("\xff")); got != "" {
+		t.Errorf("ProtoJSON(invalid) = %q, want empty", got)
+	}
+}
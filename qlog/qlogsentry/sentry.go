@@ -0,0 +1,113 @@
+// Package qlogsentry reports the entries of a qlog.Logger at ErrorLevel and
+// above as Sentry events. It is a separate package so that only the programs
+// using it depend on the Sentry SDK.
+package qlogsentry
+
+import (
+	"slices"
+	"time"
+
+	"github.com/correctinho/correct-mlt-go/qlog"
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zapcore"
+)
+
+// flushTimeout bounds how long Sync, Fatal and Panic wait for the events to be
+// delivered to Sentry.
+const flushTimeout = 2 * time.Second
+
+// tags are the context-derived fields sent as Sentry tags. Every other field
+// is sent as event extra data.
+var tags = []string{qlog.KeyXRequestID, qlog.KeyAPIRequestID, qlog.KeyService, qlog.KeyServiceVersion}
+
+// With returns a child of l that, besides its usual output, reports every
+// entry at ErrorLevel or above to the Sentry project of dsn.
+func With(l *qlog.Logger, dsn string) (*qlog.Logger, error) {
+	return WithOptions(l, sentry.ClientOptions{Dsn: dsn})
+}
+
+// WithOptions is like With but builds the Sentry client from opts, which
+// allows setting the environment, release or a custom transport.
+func WithOptions(l *qlog.Logger, opts sentry.ClientOptions) (*qlog.Logger, error) {
+	client, err := sentry.NewClient(opts)
+	if err != nil {
+		return nil, err
+	}
+	return l.WrapCore(WrapCore(client)), nil
+}
+
+// WrapCore returns a core wrapper, for qlog.Logger.WrapCore or zap.WrapCore,
+// reporting the ErrorLevel and above entries to client besides writing them to
+// the wrapped core.
+func WrapCore(client *sentry.Client) func(zapcore.Core) zapcore.Core {
+	return func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, &sentryCore{client: client})
+	}
+}
+
+// sentryCore is a zapcore.Core capturing ErrorLevel and above entries as
+// Sentry events.
+type sentryCore struct {
+	client *sentry.Client
+	fields []zapcore.Field
+}
+
+func (c *sentryCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.ErrorLevel
+}
+
+func (c *sentryCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sentryCore{
+		client: c.client,
+		fields: append(slices.Clip(c.fields), fields...),
+	}
+}
+
+func (c *sentryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+func (c *sentryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	// A wrapping core may write every entry it checked to the whole tee.
+	if !c.Enabled(ent.Level) {
+		return nil
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+	event.Message = ent.Message
+	event.Logger = ent.LoggerName
+	event.Timestamp = ent.Time
+	for k, v := range enc.Fields {
+		if s, ok := v.(string); ok && slices.Contains(tags, k) {
+			event.Tags[k] = s
+			continue
+		}
+		event.Extra[k] = v
+	}
+	if ent.Level > zapcore.ErrorLevel {
+		event.Level = sentry.LevelFatal
+	}
+	c.client.CaptureEvent(event, nil, nil)
+
+	// The process is about to panic or exit, deliver the event right away.
+	if ent.Level > zapcore.ErrorLevel {
+		c.client.Flush(flushTimeout)
+	}
+	return nil
+}
+
+func (c *sentryCore) Sync() error {
+	c.client.Flush(flushTimeout)
+	return nil
+}
@@ -0,0 +1,124 @@
+package qlogsentry
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/correctinho/correct-mlt-go/qlog"
+	"github.com/getsentry/sentry-go"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// fakeTransport is a sentry.Transport keeping the events sent in memory.
+type fakeTransport struct {
+	mu      sync.Mutex
+	events  []*sentry.Event
+	flushes int
+}
+
+func (t *fakeTransport) Configure(sentry.ClientOptions) {}
+
+func (t *fakeTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *fakeTransport) Flush(time.Duration) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.flushes++
+	return true
+}
+
+func (t *fakeTransport) sent() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// newLogger returns an observed Logger reporting to a fake transport.
+func newLogger(t *testing.T) (*qlog.Logger, *observer.ObservedLogs, *fakeTransport) {
+	t.Helper()
+	base, logs := qlog.NewObserved(nil)
+	transport := &fakeTransport{}
+	l, err := WithOptions(base, sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l, logs, transport
+}
+
+func TestWithOptions(t *testing.T) {
+	l, logs, transport := newLogger(t)
+	l = l.WithRequestID("req-1")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("failed", "order_id", 42)
+
+	events := transport.sent()
+	if len(events) != 1 {
+		t.Fatalf("sent %d events, want 1", len(events))
+	}
+	event := events[0]
+	if event.Message != "failed" || event.Level != sentry.LevelError {
+		t.Errorf("event = %q at %q, want failed at error", event.Message, event.Level)
+	}
+	if got := event.Tags[qlog.KeyXRequestID]; got != "req-1" {
+		t.Errorf("tag %s = %q, want req-1", qlog.KeyXRequestID, got)
+	}
+	if got := event.Extra["order_id"]; got != int64(42) {
+		t.Errorf("extra order_id = %v, want 42", got)
+	}
+	if n := logs.Len(); n != 3 {
+		t.Errorf("logged %d entries, want 3", n)
+	}
+}
+
+func TestSync(t *testing.T) {
+	l, _, transport := newLogger(t)
+	if err := l.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	if transport.flushes == 0 {
+		t.Error("Sync didn't flush the Sentry client")
+	}
+}
+
+func TestWithInvalidDSN(t *testing.T) {
+	if _, err := With(qlog.NewNop(), "not a dsn"); err == nil {
+		t.Error("With accepted an invalid DSN")
+	}
+}
+
+func TestOnlyErrorsReported(t *testing.T) {
+	tests := []struct {
+		name string
+		wrap func(*qlog.Logger) *qlog.Logger
+	}{
+		{"masked", func(l *qlog.Logger) *qlog.Logger { return l }},
+		{"dedup", func(l *qlog.Logger) *qlog.Logger { return l.WithDedup(time.Hour) }},
+		{"trimmed stacktrace", func(l *qlog.Logger) *qlog.Logger { return l.WithTrimmedStacktrace("github.com/correctinho") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _, transport := newLogger(t)
+			l = tt.wrap(l)
+			l.Info("info", qlog.KeyAPIKey, "sk_live_abcd1234")
+			l.Warn("warn")
+			l.Error("failed", qlog.KeyAPIKey, "sk_live_abcd1234")
+			if err := l.Sync(); err != nil {
+				t.Fatal(err)
+			}
+
+			events := transport.sent()
+			if len(events) != 1 || events[0].Message != "failed" {
+				t.Fatalf("sent %d events, want the error only", len(events))
+			}
+			if got := events[0].Extra[qlog.KeyAPIKey]; got != "****1234" {
+				t.Errorf("extra %s = %v, want it masked", qlog.KeyAPIKey, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package qlog
+
+import (
+	"regexp"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maxQueryBytes is the length beyond which LogQuery truncates the SQL text.
+const maxQueryBytes = 2048
+
+var (
+	emailPattern = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	cpfPattern   = regexp.MustCompile(`^\d{3}\.?\d{3}\.?\d{3}-?\d{2}$`)
+)
+
+// LogQuery logs, at DebugLevel, a query run by the data layer: its sql text,
+// truncated beyond 2048 bytes, its duration in milliseconds, the rows it
+// affected and its args. The string args looking like an email or a CPF are
+// replaced by "[REDACTED]".
+func (l *Logger) LogQuery(sql string, dur time.Duration, rows int64, args []interface{}) {
+	if len(sql) > maxQueryBytes {
+		sql = truncate(sql, maxQueryBytes)
+	}
+	l.log(zapcore.DebugLevel, "query", []interface{}{
+		zap.String("sql", sql),
+		Duration("duration_ms", dur),
+		zap.Int64("rows", rows),
+		zap.Any("args", redactArgs(args)),
+	})
+}
+
+// redactArgs returns args with the personal data values replaced by
+// redactedValue. args is only copied when one of them has to be replaced.
+func redactArgs(args []interface{}) []interface{} {
+	out := args
+	copied := false
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok || !isPersonalData(s) {
+			continue
+		}
+		if !copied {
+			out = append([]interface{}(nil), args...)
+			copied = true
+		}
+		out[i] = redactedValue
+	}
+	return out
+}
+
+// isPersonalData reports whether s looks like an email or a CPF.
+func isPersonalData(s string) bool {
+	return emailPattern.MatchString(s) || cpfPattern.MatchString(s)
+}
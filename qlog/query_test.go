@@ -0,0 +1,61 @@
+package qlog
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestLogQuery(t *testing.T) {
+	long := "SELECT " + strings.Repeat("id, ", maxQueryBytes) + "name FROM orders"
+	tests := []struct {
+		name     string
+		sql      string
+		args     []interface{}
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "redacted args",
+			sql:      "SELECT * FROM users WHERE email = $1 OR cpf = $2 OR cpf = $3",
+			args:     []interface{}{"ana@example.com", "123.456.789-09", "12345678909", "acme", 42},
+			wantSQL:  "SELECT * FROM users WHERE email = $1 OR cpf = $2 OR cpf = $3",
+			wantArgs: []interface{}{redactedValue, redactedValue, redactedValue, "acme", 42},
+		},
+		{
+			name:     "truncated",
+			sql:      long,
+			args:     nil,
+			wantSQL:  long[:maxQueryBytes] + truncatedSuffix,
+			wantArgs: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			args := append([]interface{}(nil), tt.args...)
+			l.LogQuery(tt.sql, 1500*time.Microsecond, 3, args)
+
+			entry := logs.All()[0]
+			if entry.Message != "query" || entry.Level != zapcore.DebugLevel {
+				t.Errorf("entry = %q at %v, want query at debug", entry.Message, entry.Level)
+			}
+			fields := entry.ContextMap()
+			if fields["sql"] != tt.wantSQL {
+				t.Errorf("sql = %.80q, want %.80q", fields["sql"], tt.wantSQL)
+			}
+			if fields["duration_ms"] != 1.5 || fields["rows"] != int64(3) {
+				t.Errorf("duration_ms = %v, rows = %v, want 1.5 and 3", fields["duration_ms"], fields["rows"])
+			}
+			if got, _ := fields["args"].([]interface{}); !reflect.DeepEqual(got, tt.wantArgs) {
+				t.Errorf("args = %v, want %v", fields["args"], tt.wantArgs)
+			}
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("LogQuery modified the args to %v", args)
+			}
+		})
+	}
+}
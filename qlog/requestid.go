@@ -0,0 +1,45 @@
+package qlog
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// ctxKey is the type of the keys qlog stores on a context.Context.
+type ctxKey int
+
+const (
+	// loggerKey holds the request-scoped *Logger.
+	loggerKey ctxKey = iota
+	// requestIDKey holds the request id read by logFromContext.
+	requestIDKey
+	// levelKey holds the per-request level override.
+	levelKey
+	// sessionIDKey holds the session id read by logFromContext.
+	sessionIDKey
+)
+
+// headerXRequestID is the header carrying the request id between services.
+const headerXRequestID = "X-Request-ID"
+
+// newRequestID returns a random (version 4) UUID to be used as request id.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request id, which is
+// logged by the Loggers bound to it and forwarded by InjectRequestID.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestIDFromContext returns the request id carried by ctx, or "" if none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
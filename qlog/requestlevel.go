@@ -0,0 +1,159 @@
+package qlog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// headerDebugLogging is the request header enabling DebugLevel entries for a
+// single request.
+const headerDebugLogging = "X-Debug-Logging"
+
+// ContextWithLevel returns a copy of ctx on which the Loggers bound to it
+// write the entries from level, even when the logger's own level is higher.
+// Only lowering the level has an effect.
+func ContextWithLevel(ctx context.Context, level LevelError) context.Context {
+	return context.WithValue(ctx, levelKey, ZapLevel(level))
+}
+
+// DebugLoggingMiddleware is a net/http middleware enabling DebugLevel entries
+// for the requests sent with the X-Debug-Logging: true header, without
+// changing the level of the other requests.
+func DebugLoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if debugLoggingRequested(r) {
+			r = r.WithContext(ContextWithLevel(r.Context(), DebugLevel))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GinDebugLogging is the gin counterpart of DebugLoggingMiddleware.
+func GinDebugLogging() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if debugLoggingRequested(c.Request) {
+			c.Request = c.Request.WithContext(ContextWithLevel(c.Request.Context(), DebugLevel))
+		}
+		c.Next()
+	}
+}
+
+// debugLoggingRequested reports whether r has a true X-Debug-Logging header.
+func debugLoggingRequested(r *http.Request) bool {
+	on, _ := strconv.ParseBool(r.Header.Get(headerDebugLogging))
+	return on
+}
+
+// requestLevel returns the level override carried by the request context ctx.
+func requestLevel(ctx interface{}) (zapcore.Level, bool) {
+	c := stdContext(ctx)
+	if c == nil {
+		return 0, false
+	}
+	lvl, ok := c.Value(levelKey).(zapcore.Level)
+	return lvl, ok
+}
+
+// withRequestLevel returns out enabled from the level override of ctx, when
+// it is lower than the level of out.
+func withRequestLevel(out *zap.Logger, ctx interface{}) *zap.Logger {
+	lvl, ok := requestLevel(ctx)
+	if !ok || lvl >= out.Level() {
+		return out
+	}
+	return out.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &requestLevelCore{Core: core, level: lvl}
+	}))
+}
+
+// requestLevelCore enables the entries from level on the wrapped core. The
+// entries below the level of the wrapped core are checked against it as if
+// logged at that level, so they still go through its sampling and routing.
+type requestLevelCore struct {
+	zapcore.Core
+	level zapcore.Level
+}
+
+func (c *requestLevelCore) Enabled(lvl zapcore.Level) bool {
+	return lvl >= c.level || c.Core.Enabled(lvl)
+}
+
+func (c *requestLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &requestLevelCore{Core: c.Core.With(fields), level: c.level}
+}
+
+func (c *requestLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level < c.level {
+		return c.Core.Check(ent, ce)
+	}
+	promoted := ent
+	if floor := zapcore.LevelOf(c.Core); promoted.Level < floor {
+		promoted.Level = floor
+	}
+	if inner := c.Core.Check(promoted, nil); inner != nil {
+		return ce.AddCore(ent, &checkedCore{Core: c.Core, checked: inner})
+	}
+	return ce
+}
+
+// checkedCore writes an entry through the cores recorded in checked, with the
+// original level of the entry.
+type checkedCore struct {
+	zapcore.Core
+	checked *zapcore.CheckedEntry
+}
+
+func (c *checkedCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var out writeErrorOutput
+	c.checked.Entry = ent
+	c.checked.ErrorOutput = &out
+	c.checked.Write(fields...)
+	return out.err(ent)
+}
+
+// writeErrorOutput is the ErrorOutput of the entries written by checkedCore.
+// It keeps the write error CheckedEntry.Write reports, so that checkedCore
+// returns it and the entry of the Logger reports it on its own ErrorOutput,
+// as zap does.
+type writeErrorOutput struct {
+	msg []byte
+}
+
+func (w *writeErrorOutput) Write(p []byte) (int, error) {
+	w.msg = append(w.msg, p...)
+	return len(p), nil
+}
+
+func (w *writeErrorOutput) Sync() error {
+	return nil
+}
+
+// err returns the error reported for ent, if any, without the time prefix
+// CheckedEntry.Write adds.
+func (w *writeErrorOutput) err(ent zapcore.Entry) error {
+	if len(w.msg) == 0 {
+		return nil
+	}
+	msg := strings.TrimSuffix(string(w.msg), "\n")
+	return errors.New(strings.TrimPrefix(msg, fmt.Sprintf("%v write error: ", ent.Time)))
+}
+
+// checked returns a core writing ent through the cores of core accepting it,
+// or nil when none does. The cores wrapping another one check the entries
+// against it with checked and write them to the result, so that only the
+// cores enabled for an entry, e.g. a single branch of a tee, write it. Their
+// write errors are returned by the result.
+func checked(core zapcore.Core, ent zapcore.Entry) zapcore.Core {
+	if inner := core.Check(ent, nil); inner != nil {
+		return &checkedCore{Core: core, checked: inner}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package qlog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap/zapcore"
+)
+
+// infoObserved returns an observed Logger enabled from InfoLevel.
+func infoObserved(t *testing.T) (*Logger, func() int) {
+	t.Helper()
+	l, logs := NewObserved(nil)
+	if err := l.SetLevel(InfoLevel); err != nil {
+		t.Fatal(err)
+	}
+	return l, func() int { return logs.FilterMessage("debug").Len() }
+}
+
+func TestContextWithLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level LevelError
+		want  bool
+	}{
+		{"lowered", DebugLevel, true},
+		{"raised", ErrorLevel, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, debugs := infoObserved(t)
+			rl := l.WithContext(ContextWithLevel(context.Background(), tt.level))
+			rl.Debug("debug")
+			rl.Info("info")
+
+			if got := rl.DebugEnabled(); got != tt.want {
+				t.Errorf("DebugEnabled() = %t, want %t", got, tt.want)
+			}
+			if got := debugs() == 1; got != tt.want {
+				t.Errorf("debug entry logged = %t, want %t", got, tt.want)
+			}
+			if !rl.InfoEnabled() {
+				t.Error("InfoEnabled() = false, want the logger level kept")
+			}
+			if l.DebugEnabled() {
+				t.Error("the override leaked to the parent logger")
+			}
+		})
+	}
+}
+
+func TestDebugLoggingMiddleware(t *testing.T) {
+	for _, header := range []string{"true", "false", ""} {
+		t.Run(header, func(t *testing.T) {
+			l, debugs := infoObserved(t)
+			h := DebugLoggingMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				l.WithContext(r).Debug("debug")
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set(headerDebugLogging, header)
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got, want := debugs(), header == "true"; (got == 1) != want {
+				t.Errorf("logged %d debug entries, want enabled %t", got, want)
+			}
+		})
+	}
+}
+
+func TestGinDebugLogging(t *testing.T) {
+	l, debugs := infoObserved(t)
+	r := gin.New()
+	r.Use(GinDebugLogging())
+	r.GET("/", func(c *gin.Context) { l.WithContext(c).Debug("debug") })
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(headerDebugLogging, "1")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got := debugs(); got != 1 {
+		t.Errorf("logged %d debug entries, want 1", got)
+	}
+}
+
+func TestRequestLevelSplit(t *testing.T) {
+	var out, errOut bytes.Buffer
+	l := newSplitLogger(ContextWithLevel(context.Background(), DebugLevel), zapcore.AddSync(&out), zapcore.AddSync(&errOut))
+	l.Debug("debug")
+	l.Error("error")
+
+	if got := decodeEntries(t, out.String()); len(got) != 1 || got[0]["message"] != "debug" {
+		t.Errorf("stdout entries = %v, want the debug one", got)
+	}
+	if got := decodeEntries(t, errOut.String()); len(got) != 1 || got[0]["message"] != "error" {
+		t.Errorf("stderr entries = %v, want the error one", got)
+	}
+}
+
+// failingWriter is a writer whose writes fail with err.
+type failingWriter struct {
+	err error
+}
+
+func (w failingWriter) Write([]byte) (int, error) { return 0, w.err }
+
+func TestRequestLevelWriteError(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	out := captureStderr(t, func() {
+		l := NewProductionWithWriter(failingWriter{errors.New("disk full")}, nil)
+		l.WithContext(ContextWithLevel(context.Background(), DebugLevel)).Debug("debug")
+	})
+
+	if n := strings.Count(out, "write error: disk full\n"); n != 1 {
+		t.Errorf("stderr = %q, want the write error reported once", out)
+	}
+}
@@ -0,0 +1,87 @@
+package qlog
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewProductionWithRing builds a Logger like NewProduction that also keeps
+// its last size JSON entries in memory, in the returned RingSink, e.g. to
+// serve them on a /debug/logs endpoint.
+func NewProductionWithRing(context interface{}, size int) (*Logger, *RingSink) {
+	ring := newRingSink(size)
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	core := zapcore.NewTee(
+		productionCore(zapcore.Lock(os.Stderr), level),
+		productionCore(ring, level),
+	)
+	return newLogger(context, core, level), ring
+}
+
+// RingSink is a zapcore.WriteSyncer keeping the last entries written to it
+// in a circular buffer. It is safe for concurrent use.
+type RingSink struct {
+	mu      sync.Mutex
+	entries [][]byte
+	next    int
+	full    bool
+}
+
+// newRingSink returns a RingSink keeping the last size entries, at least one.
+func newRingSink(size int) *RingSink {
+	if size < 1 {
+		size = 1
+	}
+	return &RingSink{entries: make([][]byte, size)}
+}
+
+// Write stores p, a single encoded entry, replacing the oldest one when the
+// buffer is full.
+func (r *RingSink) Write(p []byte) (int, error) {
+	entry := bytes.TrimSuffix(append([]byte(nil), p...), []byte("\n"))
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Sync implements zapcore.WriteSyncer; there is nothing to flush.
+func (r *RingSink) Sync() error {
+	return nil
+}
+
+// Entries returns the retained JSON entries, oldest first.
+func (r *RingSink) Entries() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	if r.full {
+		for _, e := range r.entries[r.next:] {
+			out = append(out, string(e))
+		}
+	}
+	for _, e := range r.entries[:r.next] {
+		out = append(out, string(e))
+	}
+	return out
+}
+
+// Handler returns an http.HandlerFunc serving the retained entries as
+// newline-delimited JSON, oldest first.
+func (r *RingSink) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, e := range r.Entries() {
+			_, _ = w.Write([]byte(e + "\n"))
+		}
+	}
+}
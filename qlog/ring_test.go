@@ -0,0 +1,43 @@
+package qlog
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRingSink(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	var ring *RingSink
+	out := captureStderr(t, func() {
+		var l *Logger
+		l, ring = NewProductionWithRing(nil, 2)
+		if got := ring.Entries(); len(got) != 0 {
+			t.Errorf("Entries() = %q before logging, want none", got)
+		}
+		l.Info("first")
+		l.Info("second")
+		l.Debug("hidden")
+		l.Info("third")
+	})
+	if n := len(decodeEntries(t, out)); n != 3 {
+		t.Errorf("wrote %d entries to stderr, want 3", n)
+	}
+
+	var messages []string
+	for _, entry := range decodeEntries(t, strings.Join(ring.Entries(), "\n")) {
+		messages = append(messages, entry["message"].(string))
+	}
+	if got := strings.Join(messages, ","); got != "second,third" {
+		t.Errorf("ring holds %s, want the last two entries", got)
+	}
+
+	rec := httptest.NewRecorder()
+	ring.Handler()(rec, httptest.NewRequest("GET", "/debug/logs", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q, want application/x-ndjson", ct)
+	}
+	if want := strings.Join(ring.Entries(), "\n") + "\n"; rec.Body.String() != want {
+		t.Errorf("body = %q, want %q", rec.Body.String(), want)
+	}
+}
@@ -0,0 +1,139 @@
+package qlog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Safe constructs a field logging v, typically a struct, with the value of
+// every struct field tagged `qlog:"redact"` replaced by "[REDACTED]", e.g.
+//
+//	type User struct {
+//		Email    string `json:"email"`
+//		Password string `json:"password" qlog:"redact"`
+//	}
+//
+// Nested structs, pointers, slices and maps are walked. Struct fields are
+// logged under their json tag name when set. A value reached again from
+// within itself, such as the back-pointer of a doubly linked list, is logged
+// as "[CYCLE]".
+func Safe(key string, v interface{}) zap.Field {
+	return zap.Any(key, safeValue(reflect.ValueOf(v), map[safeVisit]bool{}))
+}
+
+// cycleValue replaces a value reached again while it is being walked.
+const cycleValue = "[CYCLE]"
+
+// safeVisit identifies a pointer, map or slice being walked by safeValue.
+type safeVisit struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// safeValue returns v with its redacted struct fields replaced, at any
+// nesting level. walking holds the pointers, maps and slices v is nested in.
+func safeValue(v reflect.Value, walking map[safeVisit]bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	// Types marshaling themselves, such as time.Time, are logged as is.
+	if v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if v.IsNil() || (v.Kind() == reflect.Slice && v.Len() == 0) {
+			break
+		}
+		visit := safeVisit{v.Pointer(), v.Type(), 0}
+		if v.Kind() == reflect.Slice {
+			visit.len = v.Len()
+		}
+		if walking[visit] {
+			return cycleValue
+		}
+		walking[visit] = true
+		defer delete(walking, visit)
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return safeValue(v.Elem(), walking)
+	case reflect.Struct:
+		out := make(map[string]interface{}, v.NumField())
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			name, skip := safeFieldName(sf)
+			if skip {
+				continue
+			}
+			if sf.Tag.Get("qlog") == "redact" {
+				out[name] = redactedValue
+				continue
+			}
+			out[name] = safeValue(v.Field(i), walking)
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = safeValue(v.Index(i), walking)
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			out[mapKey(iter.Key())] = safeValue(iter.Value(), walking)
+		}
+		return out
+	}
+	return v.Interface()
+}
+
+// safeFieldName returns the name sf is logged under: its json tag name when
+// set, the field name otherwise. skip is true for a field tagged json:"-".
+func safeFieldName(sf reflect.StructField) (name string, skip bool) {
+	tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+	switch tag {
+	case "-":
+		return "", true
+	case "":
+		return sf.Name, false
+	}
+	return tag, false
+}
+
+// mapKey formats the key k of a map as a string.
+func mapKey(k reflect.Value) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	return fmt.Sprint(k.Interface())
+}
@@ -0,0 +1,92 @@
+package qlog
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type safeAddress struct {
+	Street string `json:"street" qlog:"redact"`
+	City   string `json:"city"`
+}
+
+type safeUser struct {
+	Email    string            `json:"email"`
+	Password string            `json:"password" qlog:"redact"`
+	Internal string            `json:"-"`
+	Address  *safeAddress      `json:"address"`
+	Previous []safeAddress     `json:"previous,omitempty"`
+	Labels   map[int]string    `json:"labels"`
+	Joined   time.Time         `json:"joined"`
+	Extra    map[string]string // no json tag
+	secret   string
+}
+
+type safeNode struct {
+	Name string    `json:"name"`
+	Next *safeNode `json:"next"`
+}
+
+func TestSafe(t *testing.T) {
+	joined := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	loop := &safeNode{Name: "a"}
+	loop.Next = &safeNode{Name: "b", Next: loop}
+	shared := &safeNode{Name: "a"}
+	tests := []struct {
+		name string
+		v    interface{}
+		want interface{}
+	}{
+		{
+			name: "struct",
+			v: &safeUser{
+				Email:    "ana@example.com",
+				Password: "hunter2",
+				Internal: "hidden",
+				Address:  &safeAddress{Street: "Rua A, 1", City: "Recife"},
+				Previous: []safeAddress{{Street: "Rua B, 2", City: "Natal"}},
+				Labels:   map[int]string{1: "vip"},
+				Joined:   joined,
+				secret:   "unexported",
+			},
+			want: map[string]interface{}{
+				"email":    "ana@example.com",
+				"password": redactedValue,
+				"address":  map[string]interface{}{"street": redactedValue, "city": "Recife"},
+				"previous": []interface{}{map[string]interface{}{"street": redactedValue, "city": "Natal"}},
+				"labels":   map[string]interface{}{"1": "vip"},
+				"joined":   joined,
+				"Extra":    nil,
+			},
+		},
+		{
+			name: "cycle",
+			v:    loop,
+			want: map[string]interface{}{
+				"name": "a",
+				"next": map[string]interface{}{"name": "b", "next": cycleValue},
+			},
+		},
+		{
+			name: "shared pointer",
+			v:    []*safeNode{shared, shared},
+			want: []interface{}{
+				map[string]interface{}{"name": "a", "next": nil},
+				map[string]interface{}{"name": "a", "next": nil},
+			},
+		},
+		{name: "nil", v: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := Safe("user", tt.v)
+			if f.Key != "user" {
+				t.Errorf("key = %q, want user", f.Key)
+			}
+			if !reflect.DeepEqual(f.Interface, tt.want) {
+				t.Errorf("value = %#v, want %#v", f.Interface, tt.want)
+			}
+		})
+	}
+}
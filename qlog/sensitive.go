@@ -0,0 +1,127 @@
+package qlog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// maskPrefix replaces all but the last maskVisible characters of a sensitive
+// value.
+const (
+	maskPrefix  = "****"
+	maskVisible = 4
+)
+
+// sensitiveKeys holds the field keys whose values are masked, in addition to
+// KeyAPIKey. It is shared by a Logger and all its children.
+type sensitiveKeys struct {
+	keys atomic.Pointer[map[string]struct{}]
+}
+
+// contains reports whether values logged under key must be masked.
+func (s *sensitiveKeys) contains(key string) bool {
+	if key == KeyAPIKey {
+		return true
+	}
+	if keys := s.keys.Load(); keys != nil {
+		_, ok := (*keys)[key]
+		return ok
+	}
+	return false
+}
+
+// mask returns fields with the values of the sensitive keys masked. fields is
+// only copied when one of them has to be masked.
+func (s *sensitiveKeys) mask(fields []zapcore.Field) []zapcore.Field {
+	out := fields
+	copied := false
+	for i, f := range fields {
+		if !s.contains(f.Key) {
+			continue
+		}
+		if !copied {
+			out = append([]zapcore.Field(nil), fields...)
+			copied = true
+		}
+		out[i] = zap.String(f.Key, maskValue(f))
+	}
+	return out
+}
+
+// maskValue keeps only the last four characters of a string field. Values of
+// any other type are fully masked.
+func maskValue(f zapcore.Field) string {
+	if f.Type != zapcore.StringType || len(f.String) <= maskVisible {
+		return maskPrefix
+	}
+	return maskPrefix + f.String[len(f.String)-maskVisible:]
+}
+
+// SetSensitiveKeys sets the field keys whose values are masked, showing only
+// their last four characters, on this logger and every logger sharing its
+// core. Fields logged under KeyAPIKey are always masked.
+func (l *Logger) SetSensitiveKeys(keys ...string) {
+	if l.sensitive == nil {
+		return
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[k] = struct{}{}
+	}
+	l.sensitive.keys.Store(&set)
+}
+
+// maskCore wraps core so the sensitive fields are masked wherever they were
+// added: bound with With, derived from Context or passed at the log site.
+func (s *sensitiveKeys) maskCore(core zapcore.Core) zapcore.Core {
+	return &transformCore{Core: core, transform: s.mask}
+}
+
+// transformCore is a zapcore.Core applying transform to the fields before they
+// reach the wrapped core.
+type transformCore struct {
+	zapcore.Core
+	transform func([]zapcore.Field) []zapcore.Field
+}
+
+func (c *transformCore) With(fields []zapcore.Field) zapcore.Core {
+	return &transformCore{
+		Core:      c.Core.With(c.transform(fields)),
+		transform: c.transform,
+	}
+}
+
+func (c *transformCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	// Let the wrapped core decide, it may be sampling, then write through c.
+	if next := checked(c.Core, ent); next != nil {
+		return ce.AddCore(ent, &transformCore{Core: next, transform: c.transform})
+	}
+	return ce
+}
+
+func (c *transformCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.transform(fields))
+}
+
+// WrapCore returns a child logger whose core is wrapped by fn, e.g. to tee the
+// entries to another backend as qlogsentry does. The sensitive fields stay
+// masked for the cores fn adds. The parent logger is not affected.
+func (l *Logger) WrapCore(fn func(zapcore.Core) zapcore.Core) *Logger {
+	child := *l
+	child.Zap = l.Zap.WithOptions(l.wrapCore(fn))
+	return &child
+}
+
+// wrapCore returns a zap.WrapCore option applying fn while keeping the
+// sensitive fields masked for every core fn adds.
+func (l *Logger) wrapCore(fn func(zapcore.Core) zapcore.Core) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		core = fn(core)
+		if l.sensitive != nil {
+			core = l.sensitive.maskCore(core)
+		}
+		return core
+	})
+}
@@ -0,0 +1,63 @@
+package qlog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMaskValue(t *testing.T) {
+	tests := []struct {
+		field zap.Field
+		want  string
+	}{
+		{zap.String(KeyAPIKey, "sk_live_abcd1234"), "****1234"},
+		{zap.String(KeyAPIKey, "1234"), maskPrefix},
+		{zap.String(KeyAPIKey, ""), maskPrefix},
+		{zap.Int(KeyAPIKey, 12345678), maskPrefix},
+	}
+	for _, tt := range tests {
+		if got := maskValue(tt.field); got != tt.want {
+			t.Errorf("maskValue(%v) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestMaskAPIKey(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(*Logger)
+	}{
+		{"log site", func(l *Logger) { l.Info("hello", KeyAPIKey, "sk_live_abcd1234") }},
+		{"field", func(l *Logger) { l.InfoFields("hello", zap.String(KeyAPIKey, "sk_live_abcd1234")) }},
+		{"With", func(l *Logger) { l.With(zap.String(KeyAPIKey, "sk_live_abcd1234")).Info("hello") }},
+		{"request namespace", func(l *Logger) {
+			l.WithContext(fakeEchoContext{}).WithRequestNamespace().Info("hello", KeyAPIKey, "sk_live_abcd1234")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, logs := NewObserved(nil)
+			tt.log(l)
+
+			if got := logs.All()[0].ContextMap()[KeyAPIKey]; got != "****1234" {
+				t.Errorf("%s = %v, want it masked", KeyAPIKey, got)
+			}
+		})
+	}
+}
+
+func TestSetSensitiveKeys(t *testing.T) {
+	l, logs := NewObserved(nil)
+	l.SetSensitiveKeys("token", "cpf")
+	child := l.With(zap.String("token", "tok_abcd1234"))
+	child.Info("hello", "cpf", "12345678900", "name", "ana", KeyAPIKey, "key_5678")
+
+	want := map[string]interface{}{"token": "****1234", "cpf": "****8900", "name": "ana", KeyAPIKey: "****5678"}
+	fields := logs.All()[0].ContextMap()
+	for k, v := range want {
+		if fields[k] != v {
+			t.Errorf("%s = %v, want %v", k, fields[k], v)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package qlog
+
+import (
+	"context"
+	"net/http"
+
+	stg "github.com/correctinho/correct-util-sdk-go/stg"
+	"github.com/gin-gonic/gin"
+)
+
+// Where the session id, spanning the requests of a user journey, is read
+// from: the X-Session-ID header or, when absent, the session_id cookie.
+const (
+	headerSessionID = "X-Session-ID"
+	cookieSessionID = "session_id"
+)
+
+// cookieSessionIDLen is the number of hex digits of the SHA-256 of the session
+// cookie used as session id.
+const cookieSessionIDLen = 16
+
+// ContextWithSessionID returns a copy of ctx carrying the session id, which is
+// logged under KeySessionID by the Loggers bound to it.
+func ContextWithSessionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionIDKey, id)
+}
+
+// SessionIDFromContext returns the session id carried by ctx, or "" if none.
+func SessionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(sessionIDKey).(string)
+	return id
+}
+
+// sessionID returns the session id sent with r, or "" if none. A session
+// cookie is usually a bearer credential, so its value is never used as is:
+// the id is the start of its SHA-256, stable across the requests of the
+// session.
+func sessionID(r *http.Request) string {
+	if id := r.Header.Get(headerSessionID); !stg.IsEmpty(&id) {
+		return id
+	}
+	if cookie, err := r.Cookie(cookieSessionID); err == nil && !stg.IsEmpty(&cookie.Value) {
+		return bodySHA256([]byte(cookie.Value))[:cookieSessionIDLen]
+	}
+	return ""
+}
+
+// SessionIDMiddleware is a net/http middleware reading the session id of the
+// request, from the X-Session-ID header or a hash of the session_id cookie,
+// and storing it on the request's context.Context, so the entries of every
+// request of a session can be grouped under KeySessionID. Unlike the request
+// id, no session id is generated when the request has none.
+func SessionIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := sessionID(r); !stg.IsEmpty(&id) {
+			r = r.WithContext(ContextWithSessionID(r.Context(), id))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// GinSessionID returns a gin middleware doing what SessionIDMiddleware does,
+// also storing the session id on the *gin.Context under "session_id".
+func GinSessionID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if id := sessionID(c.Request); !stg.IsEmpty(&id) {
+			c.Set(KeySessionID, id)
+			c.Request = c.Request.WithContext(ContextWithSessionID(c.Request.Context(), id))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,59 @@
+package qlog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSessionID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		cookie string
+		want   string
+	}{
+		{"header", "sess-1", "secret", "sess-1"},
+		{"cookie", "", "secret", bodySHA256([]byte("secret"))[:cookieSessionIDLen]},
+		{"none", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newRequest := func() *http.Request {
+				req := httptest.NewRequest("GET", "/", nil)
+				if tt.header != "" {
+					req.Header.Set(headerSessionID, tt.header)
+				}
+				if tt.cookie != "" {
+					req.AddCookie(&http.Cookie{Name: cookieSessionID, Value: tt.cookie})
+				}
+				return req
+			}
+
+			var got string
+			SessionIDMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+				got = SessionIDFromContext(r.Context())
+			})).ServeHTTP(httptest.NewRecorder(), newRequest())
+			if got != tt.want {
+				t.Errorf("SessionIDMiddleware stored %q, want %q", got, tt.want)
+			}
+
+			var fields map[string]interface{}
+			r := gin.New()
+			r.Use(GinSessionID())
+			r.GET("/", func(c *gin.Context) {
+				got = SessionIDFromContext(c.Request.Context())
+				fields = fieldMap(ginFields(c))
+			})
+			r.ServeHTTP(httptest.NewRecorder(), newRequest())
+			if got != tt.want {
+				t.Errorf("GinSessionID stored %q, want %q", got, tt.want)
+			}
+			if got, ok := fields[KeySessionID]; ok != (tt.want != "") || (ok && got != tt.want) {
+				t.Errorf("%s = %v, want %q", KeySessionID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package qlog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// FlushOnSignal flushes the buffered entries, as Sync does but right away even
+// with WithThrottledSync, when the process receives one of sig, SIGINT and
+// SIGTERM by default, so they aren't lost on shutdown. Once flushed, it stops
+// listening and raises the signal again: a program without its own handler
+// terminates as it would without FlushOnSignal, and a program with one, e.g.
+// signal.NotifyContext, still owns its termination but gets the signal twice.
+//
+// It returns a func that stops listening for the signals.
+func (l *Logger) FlushOnSignal(sig ...os.Signal) (stop func()) {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	}
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sig...)
+
+	var once sync.Once
+	stop = func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+	go func() {
+		select {
+		case s := <-ch:
+			_ = l.flush()
+			stop()
+			// signal.Notify disabled the default action of s until Stop.
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				_ = p.Signal(s)
+			}
+		case <-done:
+		}
+	}()
+	return stop
+}
@@ -0,0 +1,89 @@
+package qlog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// syncNotifier is a zapcore.WriteSyncer reporting its syncs on synced.
+type syncNotifier struct {
+	bytes.Buffer
+	synced chan struct{}
+}
+
+func (w *syncNotifier) Sync() error {
+	w.synced <- struct{}{}
+	return nil
+}
+
+func TestFlushOnSignal(t *testing.T) {
+	w := &syncNotifier{synced: make(chan struct{}, 1)}
+	l := NewProductionWithWriter(w, nil).WithThrottledSync(time.Hour)
+	own := make(chan os.Signal, 1)
+	signal.Notify(own, syscall.SIGUSR1)
+	defer signal.Stop(own)
+	stop := l.FlushOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.synced:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the signal didn't flush the logger")
+	}
+	select {
+	case <-own:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the other handlers didn't get the signal")
+	}
+	stop()
+	stop()
+}
+
+// TestFlushOnSignalExit runs the test binary again as a child process logging
+// with FlushOnSignal, and checks that SIGTERM still terminates it.
+func TestFlushOnSignalExit(t *testing.T) {
+	if os.Getenv("QLOG_SIGNAL_CHILD") == "1" {
+		l := NewProductionWithWriter(os.Stdout, nil)
+		l.FlushOnSignal()
+		l.Info("ready")
+		time.Sleep(time.Minute)
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFlushOnSignalExit$")
+	cmd.Env = append(os.Environ(), "QLOG_SIGNAL_CHILD=1", "LOG_LEVEL=")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	timer := time.AfterFunc(5*time.Second, func() { _ = cmd.Process.Kill() })
+	defer timer.Stop()
+	if line, err := bufio.NewReader(stdout).ReadString('\n'); !strings.Contains(line, "ready") {
+		t.Fatalf("child wrote %q, %v, want the ready entry", line, err)
+	}
+	if err := cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	var exitErr *exec.ExitError
+	if err := cmd.Wait(); !errors.As(err, &exitErr) {
+		t.Fatalf("child exited with %v, want killed by SIGTERM", err)
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); !ok || !ws.Signaled() || ws.Signal() != syscall.SIGTERM {
+		t.Errorf("child exited with %v, want killed by SIGTERM", exitErr)
+	}
+}
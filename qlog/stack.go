@@ -0,0 +1,110 @@
+package qlog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithTrimmedStacktrace returns a child logger attaching to ErrorLevel and
+// above entries a "stack" field holding only the frames of the functions whose
+// name starts with modulePrefix, e.g. "github.com/correctinho/payments", so
+// the runtime, vendored and qlog frames don't bury the frames of our code. It
+// replaces the full "stacktrace" field added by zap.
+func (l *Logger) WithTrimmedStacktrace(modulePrefix string) *Logger {
+	child := *l
+	child.Zap = l.Zap.WithOptions(
+		zap.AddStacktrace(zapcore.InvalidLevel),
+		l.wrapCore(func(core zapcore.Core) zapcore.Core {
+			return &stackCore{Core: core, prefix: modulePrefix}
+		}),
+	)
+	return &child
+}
+
+// stackCore is a zapcore.Core adding the trimmed stack to the ErrorLevel and
+// above entries.
+type stackCore struct {
+	zapcore.Core
+	prefix string
+}
+
+func (c *stackCore) With(fields []zapcore.Field) zapcore.Core {
+	return &stackCore{Core: c.Core.With(fields), prefix: c.prefix}
+}
+
+func (c *stackCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if next := checked(c.Core, ent); next != nil {
+		return ce.AddCore(ent, &stackCore{Core: next, prefix: c.prefix})
+	}
+	return ce
+}
+
+func (c *stackCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level >= zapcore.ErrorLevel {
+		if stack := trimmedStack(c.prefix); stack != "" {
+			fields = append(fields[:len(fields):len(fields)], zap.String("stack", stack))
+		}
+	}
+	return c.Core.Write(ent, fields)
+}
+
+// trimmedStack returns the stack of the calling goroutine, formatted as zap
+// does, keeping only the frames of the functions starting with prefix.
+func trimmedStack(prefix string) string {
+	return formatStack(callerFrames(), func(frame runtime.Frame) bool {
+		return strings.HasPrefix(frame.Function, prefix)
+	})
+}
+
+// panicStack returns the stack of the calling goroutine, formatted as zap
+// does, from the frame that panicked: the frames of the deferred function
+// and of the runtime handling the panic are dropped.
+func panicStack() string {
+	frames := callerFrames()
+	for i, frame := range frames {
+		if frame.Function == "runtime.gopanic" {
+			frames = frames[i+1:]
+			break
+		}
+	}
+	return formatStack(frames, func(runtime.Frame) bool { return true })
+}
+
+// callerFrames returns the frames of the stack of the calling goroutine,
+// without the qlog frames capturing it.
+func callerFrames() []runtime.Frame {
+	pcs := make([]uintptr, 128)
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	frames := runtime.CallersFrames(pcs)
+	var out []runtime.Frame
+	for {
+		frame, more := frames.Next()
+		out = append(out, frame)
+		if !more {
+			return out
+		}
+	}
+}
+
+// formatStack formats the frames for which keep returns true as zap does.
+func formatStack(frames []runtime.Frame, keep func(runtime.Frame) bool) string {
+	var b strings.Builder
+	for _, frame := range frames {
+		if !keep(frame) {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+	}
+	return b.String()
+}
@@ -0,0 +1,43 @@
+package qlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithTrimmedStacktrace(t *testing.T) {
+	const prefix = "github.com/correctinho/correct-mlt-go/qlog.TestWithTrimmedStacktrace"
+	tests := []struct {
+		name      string
+		prefix    string
+		log       func(*Logger)
+		wantStack bool
+	}{
+		{"error", prefix, func(l *Logger) { l.Error("failed") }, true},
+		{"warn", prefix, func(l *Logger) { l.Warn("slow") }, false},
+		{"no matching frame", "example.com/other", func(l *Logger) { l.Error("failed") }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base, logs := NewObserved(nil)
+			tt.log(base.WithTrimmedStacktrace(tt.prefix))
+
+			entry := logs.All()[0]
+			if entry.Stack != "" {
+				t.Errorf("entry has the full stacktrace:\n%s", entry.Stack)
+			}
+			stack, ok := entry.ContextMap()["stack"].(string)
+			if ok != tt.wantStack {
+				t.Fatalf("entry has a stack %t, want %t", ok, tt.wantStack)
+			}
+			if !ok {
+				return
+			}
+			for _, line := range strings.Split(stack, "\n") {
+				if !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, prefix) {
+					t.Errorf("stack keeps the frame %q", line)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package qlog
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// StdLogger returns a standard library *log.Logger whose output is logged
+// through l at level, with the fields derived from Context. It bridges
+// libraries that only accept a *log.Logger.
+func (l *Logger) StdLogger(level LevelError) *log.Logger {
+	// Skip the log.Logger frames so the caller is the code using it.
+	child := *l
+	child.Zap = l.Zap.WithOptions(zap.AddCallerSkip(2))
+	return log.New(&stdWriter{logger: &child, level: ZapLevel(level)}, "", 0)
+}
+
+// stdWriter logs every line written by a log.Logger.
+type stdWriter struct {
+	logger *Logger
+	level  zapcore.Level
+}
+
+func (w *stdWriter) Write(p []byte) (int, error) {
+	w.logger.log(w.level, string(bytes.TrimSuffix(p, []byte("\n"))), nil)
+	return len(p), nil
+}
+
+// Writer returns an io.WriteCloser logging through l at level each non-empty
+// line written to it, with the fields derived from Context. A partial line is
+// held until the write completing it, which suits streaming third-party
+// output, e.g. cmd.Stdout = l.Writer(InfoLevel). Close logs the partial line
+// left, if any, e.g. once cmd.Wait returns. It is safe for concurrent use.
+func (l *Logger) Writer(level LevelError) io.WriteCloser {
+	return &lineWriter{logger: l, level: ZapLevel(level)}
+}
+
+// lineWriter logs every complete line written to it.
+type lineWriter struct {
+	logger *Logger
+	level  zapcore.Level
+
+	mu      sync.Mutex
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	data := p
+	for {
+		line, rest, ok := bytes.Cut(data, []byte("\n"))
+		if !ok {
+			w.partial = append(w.partial, data...)
+			return len(p), nil
+		}
+		if len(w.partial) > 0 {
+			line = append(w.partial, line...)
+			w.partial = w.partial[:0]
+		}
+		if line = bytes.TrimSuffix(line, []byte("\r")); len(line) > 0 {
+			w.logger.log(w.level, string(line), nil)
+		}
+		data = rest
+	}
+}
+
+// Close logs the partial line held by w, if any.
+func (w *lineWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if line := bytes.TrimSuffix(w.partial, []byte("\r")); len(line) > 0 {
+		w.logger.log(w.level, string(line), nil)
+	}
+	w.partial = nil
+	return nil
+}
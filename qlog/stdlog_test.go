@@ -0,0 +1,52 @@
+package qlog
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestStdLogger(t *testing.T) {
+	l, logs := NewObserved(ContextWithRequestID(context.Background(), "req-1"))
+	l.StdLogger(WarnLevel).Printf("retrying %d", 2)
+
+	entry := logs.All()[0]
+	if entry.Message != "retrying 2" || entry.Level != zapcore.WarnLevel {
+		t.Errorf("entry = %q at %v, want retrying 2 at warn", entry.Message, entry.Level)
+	}
+	if got := entry.ContextMap()[KeyXRequestID]; got != "req-1" {
+		t.Errorf("%s = %v, want req-1", KeyXRequestID, got)
+	}
+	if !strings.HasSuffix(entry.Caller.File, "stdlog_test.go") {
+		t.Errorf("caller = %s, want the log.Logger call site", entry.Caller)
+	}
+}
+
+func TestWriter(t *testing.T) {
+	l, logs := NewObserved(nil)
+	w := l.Writer(InfoLevel)
+	for _, chunk := range []string{"first\nsec", "ond\r\n\n", "partial"} {
+		if n, err := w.Write([]byte(chunk)); n != len(chunk) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v", chunk, n, err)
+		}
+	}
+	if got := len(logs.All()); got != 2 {
+		t.Fatalf("logged %d entries before Close, want 2", got)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	var got []string
+	for _, entry := range logs.All() {
+		if entry.Level != zapcore.InfoLevel {
+			t.Errorf("%q logged at %v, want info", entry.Message, entry.Level)
+		}
+		got = append(got, entry.Message)
+	}
+	if want := []string{"first", "second", "partial"}; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("messages = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,57 @@
+package qlog
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogPriority is the priority of the frames sent by NewProductionSyslog:
+// the user-level facility at the informational severity. The severity of the
+// entry is still available in its JSON "level" field.
+const syslogPriority = 14
+
+// NewProductionSyslog builds a Logger like NewProduction that sends its JSON
+// entries to the syslog server at addr, over network ("tcp" or "udp"), as
+// RFC 3164 messages tagged with tag. It returns an error if the connection
+// can't be established. Close closes the connection.
+func NewProductionSyslog(context interface{}, network, addr, tag string) (*Logger, error) {
+	conn, err := net.Dial(network, addr)
+	if err != nil {
+		return nil, err
+	}
+	hostname, _ := os.Hostname()
+	w := &syslogWriter{
+		conn:     conn,
+		hostname: hostname,
+		tag:      tag,
+	}
+	level := zap.NewAtomicLevelAt(ZapLevel(envLevel()))
+	l := newLogger(context, productionCore(zapcore.AddSync(w), level), level)
+	l.closers = append(l.closers, conn.Close)
+	return l, nil
+}
+
+// syslogWriter frames each JSON entry written by zap as a syslog message.
+type syslogWriter struct {
+	conn     net.Conn
+	hostname string
+	tag      string
+}
+
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	var frame bytes.Buffer
+	fmt.Fprintf(&frame, "<%d>%s %s %s[%d]: ", syslogPriority,
+		time.Now().Format(time.Stamp), w.hostname, w.tag, os.Getpid())
+	frame.Write(bytes.TrimRight(p, "\n"))
+	frame.WriteByte('\n')
+	if _, err := w.conn.Write(frame.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,62 @@
+package qlog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewProductionSyslog(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "")
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	l, err := NewProductionSyslog(nil, "udp", conn.LocalAddr().String(), "payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	l.Info("hello", "user_id", 1)
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frame := string(buf[:n])
+	if !strings.HasPrefix(frame, "<14>") || !strings.HasSuffix(frame, "\n") {
+		t.Errorf("frame %q isn't a syslog message", frame)
+	}
+	tag := fmt.Sprintf(" payments[%d]: ", os.Getpid())
+	_, msg, ok := strings.Cut(frame, tag)
+	if !ok {
+		t.Fatalf("frame %q doesn't carry the tag %q", frame, tag)
+	}
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(msg), &entry); err != nil {
+		t.Fatalf("decode %q: %v", msg, err)
+	}
+	if entry["message"] != "hello" || entry["user_id"] != 1.0 {
+		t.Errorf("entry = %v", entry)
+	}
+}
+
+func TestNewProductionSyslogDialError(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	lis.Close()
+
+	if _, err := NewProductionSyslog(nil, "tcp", addr, "payments"); err == nil {
+		t.Error("NewProductionSyslog didn't fail without a server")
+	}
+}
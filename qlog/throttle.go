@@ -0,0 +1,92 @@
+package qlog
+
+import (
+	"sync"
+	"time"
+)
+
+// WithThrottledSync returns a child logger whose Sync flushes at most once
+// per interval, e.g. to avoid an fsync per request on a file-backed logger: a
+// call arriving at least interval after the last flush flushes right away and
+// returns its error, while the calls arriving within the interval are
+// coalesced into a single flush at its end and return the error of the last
+// completed flush. Close and FlushOnSignal still flush immediately, and Close
+// cancels the pending flush.
+func (l *Logger) WithThrottledSync(interval time.Duration) *Logger {
+	child := *l
+	child.syncer = newThrottledSync(child.flush, interval)
+	// Cancel the pending flush before the writers are closed under it.
+	child.closers = append([]func() error{child.syncer.stop}, l.closers...)
+	return &child
+}
+
+// throttledSync runs flush at most once per interval, deferring the calls
+// arriving too early to a flush run in the background at the end of the
+// interval.
+type throttledSync struct {
+	flush    func() error
+	interval time.Duration
+
+	mu      sync.Mutex
+	last    time.Time   // when the last flush started
+	pending *time.Timer // the deferred flush, if any
+	stopped bool
+	err     error
+}
+
+// newThrottledSync returns a throttledSync running flush.
+func newThrottledSync(flush func() error, interval time.Duration) *throttledSync {
+	return &throttledSync{flush: flush, interval: interval}
+}
+
+// Sync flushes right away when the last flush is at least interval old.
+// Otherwise it defers a flush to the end of the interval, unless one is
+// already pending, and returns the error of the last completed flush.
+func (t *throttledSync) Sync() error {
+	t.mu.Lock()
+	if wait := t.interval - time.Since(t.last); wait > 0 || t.pending != nil {
+		if t.pending == nil && !t.stopped {
+			t.pending = time.AfterFunc(wait, t.deferred)
+		}
+		err := t.err
+		t.mu.Unlock()
+		return err
+	}
+	t.last = time.Now()
+	t.mu.Unlock()
+	return t.run()
+}
+
+// deferred runs the flush deferred by Sync.
+func (t *throttledSync) deferred() {
+	t.mu.Lock()
+	t.pending = nil
+	if t.stopped {
+		t.mu.Unlock()
+		return
+	}
+	t.last = time.Now()
+	t.mu.Unlock()
+	_ = t.run()
+}
+
+// run flushes and records the error.
+func (t *throttledSync) run() error {
+	err := t.flush()
+	t.mu.Lock()
+	t.err = err
+	t.mu.Unlock()
+	return err
+}
+
+// stop cancels the deferred flush, if any; Close flushes itself.
+func (t *throttledSync) stop() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	if t.pending != nil {
+		t.pending.Stop()
+		t.pending = nil
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package qlog
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottledSync(t *testing.T) {
+	var flushes atomic.Int32
+	errFlush := errors.New("disk full")
+	ts := newThrottledSync(func() error {
+		if flushes.Add(1) == 1 {
+			return errFlush
+		}
+		return nil
+	}, 50*time.Millisecond)
+
+	if err := ts.Sync(); !errors.Is(err, errFlush) {
+		t.Errorf("first Sync() = %v, want %v", err, errFlush)
+	}
+	for i := 0; i < 3; i++ {
+		if err := ts.Sync(); !errors.Is(err, errFlush) {
+			t.Errorf("coalesced Sync() = %v, want the last flush error", err)
+		}
+	}
+	if n := flushes.Load(); n != 1 {
+		t.Fatalf("flushed %d times within the interval, want 1", n)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for flushes.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := flushes.Load(); n != 2 {
+		t.Fatalf("flushed %d times after the interval, want the coalesced calls flushed once", n)
+	}
+	if err := ts.Sync(); err != nil {
+		t.Errorf("Sync() after the deferred flush = %v, want its error", err)
+	}
+}
+
+func TestThrottledSyncStop(t *testing.T) {
+	var flushes atomic.Int32
+	ts := newThrottledSync(func() error {
+		flushes.Add(1)
+		return nil
+	}, 20*time.Millisecond)
+	_ = ts.Sync()
+	_ = ts.Sync()
+	if err := ts.stop(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if n := flushes.Load(); n != 1 {
+		t.Errorf("flushed %d times, want the deferred flush canceled", n)
+	}
+}
+
+func TestWithThrottledSync(t *testing.T) {
+	w := &syncErrWriter{err: errors.New("disk full")}
+	l := NewProductionWithWriter(w, nil).WithThrottledSync(time.Hour)
+	if err := l.Sync(); !errors.Is(err, w.err) {
+		t.Errorf("Sync() = %v, want %v", err, w.err)
+	}
+	if err := l.Close(); !errors.Is(err, w.err) {
+		t.Errorf("Close() = %v, want the immediate flush error %v", err, w.err)
+	}
+}
+
+func TestWithThrottledSyncCloseOrder(t *testing.T) {
+	base := NewNop()
+	var l *Logger
+	var stoppedFirst bool
+	base.closers = []func() error{func() error {
+		stoppedFirst = l.syncer.stopped
+		return nil
+	}}
+	l = base.WithThrottledSync(time.Hour)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !stoppedFirst {
+		t.Error("the writers were closed before the throttled sync was stopped")
+	}
+}
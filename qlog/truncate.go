@@ -0,0 +1,111 @@
+package qlog
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// truncatedSuffix is appended to the string values cut by MaxFieldBytes.
+const truncatedSuffix = "...(truncated)"
+
+// MaxFieldBytes returns an option cutting string values longer than n bytes
+// down to n bytes followed by "...(truncated)", so a single oversized value
+// can't exceed the line size limit of the log shipper. It applies to fields
+// bound with With, derived from Context and passed at the log site, including
+// the strings nested in the map logged by InfoJSON. Values of any other type
+// are left untouched. Use it with NewProductionWithOptions.
+func MaxFieldBytes(n int) zap.Option {
+	return zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return &transformCore{
+			Core: core,
+			transform: func(fields []zapcore.Field) []zapcore.Field {
+				return truncateFields(fields, n)
+			},
+		}
+	})
+}
+
+// truncateFields returns fields with their string values cut to n bytes.
+// fields is only copied when one of them has to be cut, and never when n isn't
+// positive.
+func truncateFields(fields []zapcore.Field, n int) []zapcore.Field {
+	if n <= 0 {
+		return fields
+	}
+	out := fields
+	copied := false
+	for i, f := range fields {
+		t, ok := truncateField(f, n)
+		if !ok {
+			continue
+		}
+		if !copied {
+			out = append([]zapcore.Field(nil), fields...)
+			copied = true
+		}
+		out[i] = t
+	}
+	return out
+}
+
+// truncateField returns f with its value cut to n bytes, and whether it had to
+// be cut.
+func truncateField(f zapcore.Field, n int) (zapcore.Field, bool) {
+	switch f.Type {
+	case zapcore.StringType:
+		if len(f.String) > n {
+			return zap.String(f.Key, truncate(f.String, n)), true
+		}
+	case zapcore.ByteStringType:
+		if b := f.Interface.([]byte); len(b) > n {
+			return zap.String(f.Key, truncate(string(b), n)), true
+		}
+	case zapcore.ReflectType:
+		switch v := f.Interface.(type) {
+		case map[string]interface{}, []interface{}:
+			return zap.Any(f.Key, truncateNested(f.Interface, n)), true
+		case json.RawMessage:
+			// Cutting raw JSON would make it invalid, log it as a string.
+			if len(v) > n {
+				return zap.String(f.Key, truncate(string(v), n)), true
+			}
+		}
+	}
+	return f, false
+}
+
+// truncateNested cuts the strings found inside v, at any nesting level of
+// maps and slices.
+func truncateNested(v interface{}, n int) interface{} {
+	switch value := v.(type) {
+	case string:
+		if len(value) > n {
+			return truncate(value, n)
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = truncateNested(item, n)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = truncateNested(item, n)
+		}
+		return out
+	}
+	return v
+}
+
+// truncate cuts s to at most n bytes, without splitting a character, and
+// appends truncatedSuffix.
+func truncate(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + truncatedSuffix
+}
@@ -0,0 +1,48 @@
+package qlog
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"abcdef", 3, "abc" + truncatedSuffix},
+		{"aé", 2, "a" + truncatedSuffix},
+		{"éé", 1, truncatedSuffix},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.s, tt.n); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestMaxFieldBytes(t *testing.T) {
+	long := strings.Repeat("x", 20)
+	cut := strings.Repeat("x", 8) + truncatedSuffix
+	out := captureStderr(t, func() {
+		l := NewProductionWithOptions(nil, MaxFieldBytes(8)).With(zap.String("bound", long))
+		l.Info("hello", "s", long, "short", "ok", "n", 123456789012)
+		l.InfoJSON("json", `{}`, LoggerExtras{Key: "extra", Value: map[string]interface{}{
+			"nested": []interface{}{long},
+		}})
+	})
+
+	entries := decodeEntries(t, out)
+	for k, want := range map[string]interface{}{"bound": cut, "s": cut, "short": "ok", "n": 123456789012.0} {
+		if got := entries[0][k]; got != want {
+			t.Errorf("%s = %v, want %v", k, got, want)
+		}
+	}
+	extra, _ := entries[1]["extra"].(map[string]interface{})
+	if nested, _ := extra["nested"].([]interface{}); len(nested) != 1 || nested[0] != cut {
+		t.Errorf("extra = %v, want the nested string cut", entries[1]["extra"])
+	}
+}
@@ -0,0 +1,36 @@
+package qlog
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// keyValidationErrors is the key of the field logged by ValidationError.
+const keyValidationErrors = "validation_errors"
+
+// ValidationError constructs a field logging the violations of err, when it
+// is or wraps a validator.ValidationErrors, as an array of objects with the
+// "field", "tag" and "param" of each violation, e.g.
+// [{"field":"Email","tag":"email","param":""}]. Any other error is logged as
+// its message. The field is logged under "validation_errors"; a nil err adds
+// no field.
+func ValidationError(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+	var ves validator.ValidationErrors
+	if !errors.As(err, &ves) {
+		return zap.String(keyValidationErrors, err.Error())
+	}
+	violations := make([]map[string]string, 0, len(ves))
+	for _, fe := range ves {
+		violations = append(violations, map[string]string{
+			"field": fe.Field(),
+			"tag":   fe.Tag(),
+			"param": fe.Param(),
+		})
+	}
+	return zap.Any(keyValidationErrors, violations)
+}
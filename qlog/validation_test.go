@@ -0,0 +1,52 @@
+package qlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type signup struct {
+	Email string `validate:"required,email"`
+	Age   int    `validate:"gte=18"`
+}
+
+func TestValidationError(t *testing.T) {
+	err := validator.New().Struct(signup{Email: "ana", Age: 16})
+	tests := []struct {
+		name string
+		err  error
+		want interface{}
+	}{
+		{
+			name: "violations",
+			err:  fmt.Errorf("bind signup: %w", err),
+			want: []interface{}{
+				map[string]interface{}{"field": "Email", "tag": "email", "param": ""},
+				map[string]interface{}{"field": "Age", "tag": "gte", "param": "18"},
+			},
+		},
+		{name: "other error", err: errors.New("unexpected EOF"), want: "unexpected EOF"},
+		{name: "nil", err: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("LOG_LEVEL", "")
+			var buf bytes.Buffer
+			NewProductionWithWriter(&buf, nil).Warn("invalid request", ValidationError(tt.err))
+
+			entries := decodeEntries(t, buf.String())
+			if len(entries) != 1 {
+				t.Fatalf("logged %d entries, want 1: %s", len(entries), buf.String())
+			}
+			got, ok := entries[0][keyValidationErrors]
+			if ok != (tt.want != nil) || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s = %v, want %v", keyValidationErrors, got, tt.want)
+			}
+		})
+	}
+}